@@ -12,7 +12,8 @@ import (
 var _ Engine = (*Badger)(nil)
 
 type Badger struct {
-	db *badger.DB
+	db         *badger.DB
+	durability string
 }
 
 func (*Badger) Version(_ *Benchmark) (string, errors.E) {
@@ -27,11 +28,18 @@ func (e *Badger) Sync() errors.E {
 	return errors.WithStack(e.db.Sync())
 }
 
+func (e *Badger) Durability() string {
+	return e.durability
+}
+
 func (e *Badger) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	tx := e.db.NewTransaction(false)
 
 	item, err := tx.Get(key)
-	if err != nil {
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		tx.Discard()
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
 		tx.Discard()
 		return nil, errors.WithStack(err)
 	}
@@ -51,7 +59,7 @@ func (e *Badger) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 }
 
 func (e *Badger) Init(benchmark *Benchmark, logger zerolog.Logger) errors.E {
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
 	// Default options already have ValueLogFileSize at maximum value (2 GB).
@@ -61,9 +69,14 @@ func (e *Badger) Init(benchmark *Benchmark, logger zerolog.Logger) errors.E {
 	opts = opts.WithCompression(options.None)
 	// When compression is disabled, cache size should be 0, says documentation.
 	opts = opts.WithBlockCacheSize(0)
-	// To be able to compare between engines, we make all of them sync after every write.
-	// This lowers throughput, but it makes relative differences between engines clearer.
-	opts = opts.WithSyncWrites(true)
+	if syncsWrites(benchmark.Durability) {
+		opts = opts.WithSyncWrites(true)
+		// Badger batches concurrent writes and syncs them together.
+		e.durability = durabilityGroup
+	} else {
+		opts = opts.WithSyncWrites(false)
+		e.durability = benchmark.Durability
+	}
 	opts = opts.WithLogger(loggerWrapper{logger})
 	db, err := badger.Open(opts)
 	if err != nil {
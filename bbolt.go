@@ -16,7 +16,8 @@ var bboltBucketName = []byte("data") //nolint:gochecknoglobals
 var _ Engine = (*Bbolt)(nil)
 
 type Bbolt struct {
-	db *bolt.DB
+	db         *bolt.DB
+	durability string
 }
 
 func (*Bbolt) Version(_ *Benchmark) (string, errors.E) {
@@ -31,6 +32,10 @@ func (e *Bbolt) Sync() errors.E {
 	return errors.WithStack(e.db.Sync())
 }
 
+func (e *Bbolt) Durability() string {
+	return e.durability
+}
+
 func (e *Bbolt) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	tx, err := e.db.Begin(false)
 	if err != nil {
@@ -39,7 +44,7 @@ func (e *Bbolt) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 
 	value := tx.Bucket(bboltBucketName).Get(key)
 	if value == nil {
-		return nil, errors.Join(errors.New("does not exist"), tx.Rollback())
+		return nil, errors.Join(errors.WithStack(ErrNotFound), tx.Rollback())
 	}
 	return bytesReadSeekCloser(value, func() error {
 		return errors.WithStack(tx.Rollback())
@@ -51,18 +56,26 @@ func (e *Bbolt) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
+	switch benchmark.Durability {
+	case durabilityNone, durabilityPeriodic, durabilityGroup:
+		e.durability = benchmark.Durability
+	default:
+		// Bbolt always uses fdatasync on Linux.
+		e.durability = durabilityFdatasync
+	}
 	db, err := bolt.Open(path.Join(benchmark.Data, "data.db"), 0o600, &bolt.Options{ //nolint:exhaustruct,gomnd
 		Timeout:      5 * time.Second, //nolint:gomnd
 		FreelistType: bolt.FreelistMapType,
+		NoSync:       !syncsWrites(e.durability),
 	})
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucket(bboltBucketName) //nolint:govet
+		_, err := tx.CreateBucketIfNotExists(bboltBucketName) //nolint:govet
 		return errors.WithStack(err)
 	})
 	if err != nil {
@@ -77,6 +90,13 @@ func (*Bbolt) Name() string {
 }
 
 func (e *Bbolt) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedreturns
+	if e.durability == durabilityGroup {
+		// Batch combines concurrent writes into one transaction.
+		return errors.WithStack(e.db.Batch(func(tx *bolt.Tx) error {
+			return errors.WithStack(tx.Bucket(bboltBucketName).Put(key, value))
+		}))
+	}
+
 	tx, err := e.db.Begin(true)
 	if err != nil {
 		return errors.WithStack(err)
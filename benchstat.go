@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/c2h5oh/datasize"
+	"gitlab.com/tozd/go/errors"
+)
+
+// benchstatName returns a Go benchmark name for the operation in the run,
+// e.g., "BenchmarkEngine/badger/r=1/w=1/size=1MB/op=set-8".
+func benchstatName(results *Results, operation string) string {
+	name := fmt.Sprintf(
+		"BenchmarkEngine/%s/r=%d/w=%d/size=%s",
+		results.Engine, results.Readers, results.Writers, datasize.ByteSize(results.Size),
+	)
+	if results.Vary {
+		name += "/vary=true"
+	}
+	return fmt.Sprintf("%s/op=%s-%d", name, operation, results.Threads)
+}
+
+// benchstatConfig returns a benchstat configuration key. Keys cannot contain spaces
+// and upper case letters.
+func benchstatConfig(key, value string) string {
+	return fmt.Sprintf("%s: %s\n", strings.ToLower(key), value)
+}
+
+// writeBenchstat writes results in the Go benchmark format so that they can be
+// compared using benchstat. Every metrics interval is written as its own line
+// (like using "go test -count") so that benchstat can compute confidence intervals.
+//
+// Values are latencies of individual operations and not wall time divided by
+// the number of operations as done by "go test", because operations run concurrently.
+func writeBenchstat(path string, results *Results) (errE errors.E) { //nolint:nonamedreturns
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		errE = errors.Join(errE, f.Close())
+	}()
+
+	w := bufio.NewWriter(f)
+
+	_, err = w.WriteString(
+		benchstatConfig("goos", runtime.GOOS) +
+			benchstatConfig("goarch", runtime.GOARCH) +
+			benchstatConfig("pkg", "gitlab.com/go-benchmark-kvstore/go-benchmark-kvstore") +
+			benchstatConfig("cpu", results.CPU) +
+			benchstatConfig("fs", results.FS) +
+			benchstatConfig("durability", results.Durability) +
+			benchstatConfig("engine-version", results.EngineVersion) +
+			benchstatConfig("go-runtime", results.GoRuntime),
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, interval := range results.Intervals {
+		for _, name := range resultsSamples {
+			op, ok := interval.Operations[name]
+			if !ok || op.Count == 0 {
+				continue
+			}
+			// Latencies are in milliseconds.
+			_, err = fmt.Fprintf(w, "%s\t%d\t%.0f ns/op\t%.2f MB/s\n", //nolint:gomnd
+				benchstatName(results, name), op.Count, op.Mean*1e6, op.Throughput/1e6,
+			)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	return errors.WithStack(w.Flush())
+}
@@ -11,7 +11,8 @@ import (
 var _ Engine = (*Bitcask)(nil)
 
 type Bitcask struct {
-	db bitcask.DB
+	db         bitcask.DB
+	durability string
 }
 
 func (*Bitcask) Version(_ *Benchmark) (string, errors.E) {
@@ -26,11 +27,18 @@ func (e *Bitcask) Sync() errors.E {
 	return errors.WithStack(e.db.Sync())
 }
 
+func (e *Bitcask) Durability() string {
+	return e.durability
+}
+
 func (e *Bitcask) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	tx := e.db.Transaction()
 
 	value, err := tx.Get(key)
-	if err != nil {
+	if errors.Is(err, bitcask.ErrKeyNotFound) {
+		tx.Discard()
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
 		tx.Discard()
 		return nil, errors.WithStack(err)
 	}
@@ -43,16 +51,20 @@ func (e *Bitcask) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 func (e *Bitcask) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	// We set the max value to 6 GB so that we can test values larger than 2 GB.
 	maxValueSize := 6 * 1024 * 1024 * 1024 //nolint:gomnd
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
+	if syncsWrites(benchmark.Durability) {
+		// Bitcask always uses fsync.
+		e.durability = durabilityFsync
+	} else {
+		e.durability = benchmark.Durability
+	}
 	db, err := bitcask.Open(
 		benchmark.Data,
 		bitcask.WithMaxDatafileSize(2*maxValueSize), //nolint:gomnd
 		bitcask.WithMaxValueSize(uint64(maxValueSize)),
-		// To be able to compare between engines, we make all of them sync after every write.
-		// This lowers throughput, but it makes relative differences between engines clearer.
-		bitcask.WithSync(true),
+		bitcask.WithSync(syncsWrites(benchmark.Durability)),
 	)
 	if err != nil {
 		return errors.WithStack(err)
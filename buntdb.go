@@ -15,7 +15,8 @@ import (
 var _ Engine = (*Buntdb)(nil)
 
 type Buntdb struct {
-	db *buntdb.DB
+	db         *buntdb.DB
+	durability string
 }
 
 func (*Buntdb) Version(_ *Benchmark) (string, errors.E) {
@@ -32,6 +33,10 @@ func (e *Buntdb) Sync() errors.E {
 	return nil
 }
 
+func (e *Buntdb) Durability() string {
+	return e.durability
+}
+
 func (e *Buntdb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	tx, err := e.db.Begin(false)
 	if err != nil {
@@ -39,7 +44,9 @@ func (e *Buntdb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	}
 
 	value, err := tx.Get(x.ByteSlice2String(key))
-	if err != nil {
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return nil, errors.Join(errors.WrapWith(err, ErrNotFound), tx.Rollback())
+	} else if err != nil {
 		return nil, errors.Join(err, tx.Rollback())
 	}
 	return bytesReadSeekCloser(x.String2ByteSlice(value), func() error {
@@ -52,7 +59,7 @@ func (e *Buntdb) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
 	db, err := buntdb.Open(path.Join(benchmark.Data, "data.db"))
@@ -64,9 +71,19 @@ func (e *Buntdb) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	// To be able to compare between engines, we make all of them sync after every write.
-	// This lowers throughput, but it makes relative differences between engines clearer.
-	config.SyncPolicy = buntdb.Always
+	switch benchmark.Durability {
+	case durabilityNone:
+		config.SyncPolicy = buntdb.Never
+		e.durability = durabilityNone
+	case durabilityPeriodic:
+		// Buntdb syncs every second itself.
+		config.SyncPolicy = buntdb.EverySecond
+		e.durability = durabilityPeriodic
+	default:
+		// Buntdb always uses fsync.
+		config.SyncPolicy = buntdb.Always
+		e.durability = durabilityFsync
+	}
 	err = db.SetConfig(config)
 	if err != nil {
 		return errors.WithStack(err)
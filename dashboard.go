@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"gitlab.com/tozd/go/errors"
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+const dashboardRefresh = time.Second
+
+// dashboard renders current measurements to the terminal while the benchmark runs.
+// Measurements are from the last completed metrics interval, while elapsed and
+// remaining time and resource usage refresh more often.
+type dashboard struct {
+	tty       *os.File
+	state     *term.State
+	benchmark *Benchmark
+	results   *Results
+	sink      *resultsSink
+	start     time.Time
+
+	lastTime  time.Time
+	lastUsage unix.Rusage
+}
+
+func newDashboard(benchmark *Benchmark, results *Results, sink *resultsSink) (*dashboard, errors.E) {
+	// We use the controlling terminal directly so that it works also when stdout is redirected.
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.WithMessage(err, "dashboard requires a terminal")
+	}
+	// Raw mode so that we can read single key presses.
+	state, err := term.MakeRaw(int(tty.Fd()))
+	if err != nil {
+		return nil, errors.Join(err, tty.Close())
+	}
+	// Switch to the alternate screen and hide the cursor.
+	_, err = tty.WriteString("\x1b[?1049h\x1b[?25l")
+	if err != nil {
+		return nil, errors.Join(err, term.Restore(int(tty.Fd()), state), tty.Close())
+	}
+	d := &dashboard{
+		tty:       tty,
+		state:     state,
+		benchmark: benchmark,
+		results:   results,
+		sink:      sink,
+		start:     time.Now(),
+		lastTime:  time.Now(),
+		lastUsage: unix.Rusage{}, //nolint:exhaustruct
+	}
+	_ = unix.Getrusage(unix.RUSAGE_SELF, &d.lastUsage)
+	return d, nil
+}
+
+func (d *dashboard) Close() errors.E {
+	_, err := d.tty.WriteString("\x1b[?25h\x1b[?1049l")
+	return errors.Join(err, term.Restore(int(d.tty.Fd()), d.state), d.tty.Close())
+}
+
+// Run renders the dashboard until ctx is done. Pressing "q" or Ctrl-C calls stop.
+func (d *dashboard) Run(ctx context.Context, stop context.CancelFunc) errors.E {
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			// Read returns an error once the tty is closed.
+			_, err := d.tty.Read(buf)
+			if err != nil {
+				return
+			}
+			// In raw mode Ctrl-C is not converted into a signal, so we handle it ourselves.
+			if buf[0] == 'q' || buf[0] == 'Q' || buf[0] == 3 {
+				stop()
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	for {
+		errE := d.render()
+		if errE != nil {
+			return errE
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *dashboard) render() errors.E {
+	var buf bytes.Buffer
+	// In raw mode we have to return the carriage ourselves.
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\x1b[K\r\n")
+	}
+
+	buf.WriteString("\x1b[H")
+
+	elapsed := time.Since(d.start)
+	remaining := max(d.benchmark.Time-elapsed, 0)
+	line(
+		"%s %s, %d readers, %d writers, %s values, vary %t",
+		d.results.Engine, d.results.EngineVersion, d.results.Readers, d.results.Writers,
+		datasize.ByteSize(d.results.Size).HR(), d.results.Vary,
+	)
+	line(
+		"elapsed %s, remaining %s (press q to stop)",
+		elapsed.Truncate(time.Second), remaining.Truncate(time.Second),
+	)
+	line("")
+
+	last, ok := d.sink.last()
+	if ok {
+		line(
+			"last interval %s - %s, latencies in ms",
+			last.Start, last.Start+last.Duration,
+		)
+	} else {
+		line("waiting for the first interval to complete, latencies in ms")
+	}
+	line("%-10s %12s %10s %10s %10s %10s %10s %10s", "operation", "ops/s", "MB/s", "mean", "p50", "p90", "p99", "max")
+	for _, name := range resultsSamples {
+		op, ok := last.Operations[name]
+		if !ok {
+			line("%-10s %12s %10s %10s %10s %10s %10s %10s", name, "-", "-", "-", "-", "-", "-", "-")
+			continue
+		}
+		line(
+			"%-10s %12.1f %10.2f %10.3f %10.3f %10.3f %10.3f %10.3f",
+			name, op.Rate, op.Throughput/1e6, op.Mean, op.P50, op.P90, op.P99, op.Max, //nolint:gomnd
+		)
+	}
+	line("")
+
+	line("%s", d.resources())
+
+	// Clear the rest of the screen.
+	buf.WriteString("\x1b[J")
+
+	_, err := d.tty.Write(buf.Bytes())
+	return errors.WithStack(err)
+}
+
+func (d *dashboard) resources() string {
+	resources := []string{}
+
+	now := time.Now()
+	var usage unix.Rusage
+	err := unix.Getrusage(unix.RUSAGE_SELF, &usage)
+	if err == nil {
+		cpu := time.Duration(usage.Utime.Nano()+usage.Stime.Nano()) -
+			time.Duration(d.lastUsage.Utime.Nano()+d.lastUsage.Stime.Nano())
+		resources = append(resources, fmt.Sprintf("cpu %.0f%%", 100*cpu.Seconds()/now.Sub(d.lastTime).Seconds())) //nolint:gomnd
+		d.lastUsage = usage
+		d.lastTime = now
+	}
+
+	// Second field is resident set size in pages.
+	statm, err := os.ReadFile("/proc/self/statm")
+	if err == nil {
+		fields := strings.Fields(string(statm))
+		if len(fields) > 1 {
+			pages, err := strconv.ParseUint(fields[1], 10, 64) //nolint:govet
+			if err == nil {
+				resources = append(resources, fmt.Sprintf("rss %s", datasize.ByteSize(pages*uint64(os.Getpagesize())).HR()))
+			}
+		}
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	resources = append(resources,
+		fmt.Sprintf("heap %s", datasize.ByteSize(memStats.HeapAlloc).HR()),
+		fmt.Sprintf("goroutines %d", runtime.NumGoroutine()),
+	)
+
+	var statfs unix.Statfs_t
+	err = unix.Statfs(d.benchmark.Data, &statfs)
+	if err == nil {
+		used := (statfs.Blocks - statfs.Bfree) * uint64(statfs.Bsize)
+		total := statfs.Blocks * uint64(statfs.Bsize)
+		resources = append(resources, fmt.Sprintf("disk %s/%s", datasize.ByteSize(used).HR(), datasize.ByteSize(total).HR()))
+	}
+
+	return strings.Join(resources, ", ")
+}
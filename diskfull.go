@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"crawshaw.io/sqlite"
+	"github.com/PowerDNS/lmdb-go/lmdb"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gitlab.com/tozd/go/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"golang.org/x/sys/unix"
+	sqlitego "modernc.org/sqlite"
+	sqlitelib "modernc.org/sqlite/lib"
+)
+
+// PostgreSQL error code for disk_full.
+const postgresDiskFull = "53100"
+
+// How many stored keys per writer to read back after the disk fills up.
+const diskFullChecks = 100
+
+// isDiskFull returns true if err is how the engine reports that the disk is full.
+// Injected faults are not considered as the disk being full.
+func isDiskFull(err error) bool {
+	return isNoSpace(err) && !isFault(err)
+}
+
+// isNoSpace returns true if err is how the engine reports that there is no space left,
+// including injected faults.
+func isNoSpace(err error) bool {
+	if errors.Is(err, unix.ENOSPC) || errors.Is(err, unix.EDQUOT) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == postgresDiskFull {
+		return true
+	}
+	if errors.Is(err, rpctypes.ErrNoSpace) {
+		return true
+	}
+	var lmdbErr *lmdb.OpError
+	if errors.As(err, &lmdbErr) && lmdbErr.Errno == lmdb.MapFull {
+		return true
+	}
+	var sqliteErr sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code&0xff == sqlite.SQLITE_FULL { //nolint:gomnd
+		return true
+	}
+	var sqliteGoErr *sqlitego.Error
+	if errors.As(err, &sqliteGoErr) && sqliteGoErr.Code()&0xff == sqlitelib.SQLITE_FULL { //nolint:gomnd
+		return true
+	}
+	// Some engines format errors instead of wrapping them.
+	return strings.Contains(err.Error(), unix.ENOSPC.Error())
+}
+
+// diskFull stops the workload on the first disk-full failure and records it.
+type diskFull struct {
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	errE   errors.E
+}
+
+// stop returns true if errE is a disk-full failure, in which case the workload is stopped.
+func (d *diskFull) stop(errE errors.E) bool {
+	if !isDiskFull(errE) {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Other writers might fail as well before they stop, but we record only the first failure.
+	if d.errE == nil {
+		d.errE = errE
+		d.cancel()
+	}
+	return true
+}
+
+func (d *diskFull) err() errors.E {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.errE
+}
+
+// checkReadable reads back a sample of stored keys of every writer, always including the
+// last one, and returns how many keys were checked, how many of them were readable and
+// with expected values, and the first failure.
+func checkReadable(
+	engine Engine, writeData []byte, size uint64, vary bool, total uint64, counts []*atomic.Uint64,
+) (uint64, uint64, errors.E) {
+	var checked, readable uint64
+	var firstErrE errors.E
+	for w, c := range counts {
+		stored := c.Load()
+		step := stored / diskFullChecks
+		if step == 0 {
+			step = 1
+		}
+		for i := int64(stored) - 1; i >= 0; i -= int64(step) {
+			checked++
+			key, expected := keyValue(writeData, size, vary, uint64(i)*total+uint64(w))
+			errE := verifyValue(engine, key[:], expected)
+			if errE != nil {
+				if firstErrE == nil {
+					errors.Details(errE)["key"] = key.String()
+					firstErrE = errE
+				}
+				continue
+			}
+			readable++
+		}
+	}
+	return checked, readable, firstErrE
+}
+
+// verifyValue returns an error if the value stored under key cannot be read or
+// differs from expected.
+func verifyValue(engine Engine, key, expected []byte) errors.E {
+	reader, errE := engine.Get(key)
+	if errE != nil {
+		return errE
+	}
+	value, err := io.ReadAll(reader)
+	err2 := reader.Close()
+	if err != nil || err2 != nil {
+		return errors.Join(err, err2)
+	}
+	if !bytes.Equal(value, expected) {
+		return errors.New("unexpected value")
+	}
+	return nil
+}
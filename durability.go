@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+
+	"gitlab.com/tozd/go/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Durability modes. Engines map the requested mode to their native settings
+// and report which mode they achieved, because not all engines support all modes.
+const (
+	// Writes are never explicitly synced.
+	durabilityNone = "none"
+	// Every write is synced with fsync before it returns.
+	durabilityFsync = "fsync"
+	// Every write is synced with fdatasync before it returns.
+	durabilityFdatasync = "fdatasync"
+	// Writes are not synced, but the benchmark calls Sync periodically
+	// (or the engine syncs periodically itself).
+	durabilityPeriodic = "periodic"
+	// Writes are synced before they return, but concurrent writes share syncs.
+	durabilityGroup = "group"
+)
+
+// syncsWrites returns true if durability requires writes to be synced before they return.
+func syncsWrites(durability string) bool {
+	return durability != durabilityNone && durability != durabilityPeriodic
+}
+
+// syncFile syncs the file using fsync or fdatasync.
+func syncFile(f *os.File, durability string, faults *faults) errors.E {
+	var err error
+	if durability == durabilityFdatasync {
+		err = faults.sync(func() error {
+			return unix.Fdatasync(int(f.Fd()))
+		})
+	} else {
+		err = faults.sync(f.Sync)
+	}
+	return errors.WithStack(err)
+}
+
+// syncDir syncs the directory so that newly created or renamed files in it are durable.
+func syncDir(name string) (errE errors.E) { //nolint:nonamedreturns
+	dir, err := os.Open(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		errE = errors.Join(errE, dir.Close())
+	}()
+	return errors.WithStack(dir.Sync())
+}
+
+// syncFS syncs the whole file system containing dir.
+func syncFS(dir string) (errE errors.E) { //nolint:nonamedreturns
+	d, err := os.Open(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		errE = errors.Join(errE, d.Close())
+	}()
+	return errors.WithStack(unix.Syncfs(int(d.Fd())))
+}
@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-metrics"
+	"github.com/jackc/pgx/v5"
+	"github.com/pbnjay/memory"
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	testReaders   = 4
+	testWriters   = 4
+	testValueSize = 1024
+	testLargeSize = 16 * 1024 * 1024
+	// Memory needed to open immudb.
+	testImmudbMemory = 8 * 1024 * 1024 * 1024
+)
+
+// newEngine returns a new instance of the same engine type as engine.
+func newEngine(engine Engine) Engine {
+	return reflect.New(reflect.TypeOf(engine).Elem()).Interface().(Engine) //nolint:forcetypeassert
+}
+
+func isPostgres(engine Engine) bool {
+	return strings.HasPrefix(engine.Name(), "postgres")
+}
+
+// isVolatileEngine returns true for engines which keep data only in memory.
+// The redis engine does so because tests use its in-process stand-in.
+func isVolatileEngine(engine Engine) bool {
+	return isVolatile(engine, &Benchmark{}) //nolint:exhaustruct
+}
+
+// resetPostgres removes all data the engine might have created.
+func resetPostgres(t *testing.T, engine Engine, uri string) {
+	t.Helper()
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	if engine.Name() == "postgreslo" {
+		// Large objects are not removed together with the table.
+		_, _ = conn.Exec(ctx, `SELECT lo_unlink(value) FROM kv`)
+	}
+	_, err = conn.Exec(ctx, `DROP TABLE IF EXISTS kv`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(ctx, `DROP SEQUENCE IF EXISTS kv_value_seq`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testBenchmark(t *testing.T, engine Engine) *Benchmark {
+	t.Helper()
+
+	b := &Benchmark{ //nolint:exhaustruct
+		Engine:     engine.Name(),
+		Data:       t.TempDir(),
+		Postgres:   os.Getenv("POSTGRES"),
+		Durability: durabilityFsync,
+		Readers:    testReaders,
+		Writers:    testWriters,
+		Size:       testLargeSize,
+	}
+
+	if isPostgres(engine) {
+		if b.Postgres == "" {
+			t.Skip("POSTGRES environment variable is not set")
+		}
+		resetPostgres(t, engine, b.Postgres)
+		t.Cleanup(func() {
+			resetPostgres(t, engine, b.Postgres)
+		})
+	}
+
+	return b
+}
+
+// initEngine initializes a new instance of the engine. The returned function closes
+// the engine, and it is called at the end of the test if it has not been called before.
+func initEngine(t *testing.T, engine Engine, b *Benchmark) (Engine, func()) {
+	t.Helper()
+
+	// Immudb allocates a buffer of its 6 GB max value length when opened,
+	// which fails on machines with less memory than that.
+	if engine.Name() == "immudb" && memory.TotalMemory() < testImmudbMemory {
+		t.Skip("not enough memory for immudb")
+	}
+
+	e := newEngine(engine)
+	errE := e.Init(b, zerolog.Nop())
+	if errE != nil {
+		t.Fatalf("% -+#.1v", errE)
+	}
+
+	closed := false
+	closeEngine := func() {
+		if closed {
+			return
+		}
+		closed = true
+		errE := e.Close()
+		if errE != nil {
+			t.Errorf("% -+#.1v", errE)
+		}
+	}
+	t.Cleanup(closeEngine)
+
+	return e, closeEngine
+}
+
+func setValue(t *testing.T, engine Engine, key, value []byte) {
+	t.Helper()
+
+	errE := engine.Set(key, value)
+	if errE != nil {
+		t.Fatalf("% -+#.1v", errE)
+	}
+}
+
+func getValue(engine Engine, key []byte) ([]byte, errors.E) {
+	reader, errE := engine.Get(key)
+	if errE != nil {
+		return nil, errE
+	}
+	size, errE := sizeFunc(reader)
+	if errE != nil {
+		return nil, errors.Join(errE, reader.Close())
+	}
+	value, err := io.ReadAll(reader)
+	err2 := reader.Close()
+	if err != nil || err2 != nil {
+		return nil, errors.Join(err, err2)
+	}
+	if int64(len(value)) != size {
+		return nil, errors.Errorf("size %d does not match value length %d", size, len(value))
+	}
+	return value, nil
+}
+
+func checkValue(t *testing.T, engine Engine, key, expected []byte) {
+	t.Helper()
+
+	value, errE := getValue(engine, key)
+	if errE != nil {
+		t.Fatalf("% -+#.1v", errE)
+	}
+	if !bytes.Equal(value, expected) {
+		t.Fatalf("unexpected value for key %x: got %d bytes, expected %d bytes", key, len(value), len(expected))
+	}
+}
+
+func randomValue(seed int64, size int) []byte {
+	value := make([]byte, size)
+	_, _ = rand.New(rand.NewSource(seed)).Read(value) //nolint:gosec
+	return value
+}
+
+// skipUnsupported skips the test if the engine depends on a feature
+// the file system does not support (e.g., reflinks for fsclone).
+func skipUnsupported(t *testing.T, engine Engine) {
+	t.Helper()
+
+	errE := engine.Set([]byte("probe"), []byte("probe"))
+	if errE == nil {
+		_, errE = getValue(engine, []byte("probe"))
+	}
+	if errors.Is(errE, unix.EOPNOTSUPP) || errors.Is(errE, unix.ENOTTY) {
+		t.Skipf("not supported on this file system: %s", errE.Error())
+	}
+}
+
+func TestEngines(t *testing.T) {
+	t.Parallel()
+
+	for _, engine := range engines {
+		engine := engine
+
+		t.Run(engine.Name(), func(t *testing.T) {
+			// Postgres engines share the same database so they cannot run in parallel.
+			if !isPostgres(engine) {
+				t.Parallel()
+			}
+
+			b := testBenchmark(t, engine)
+			e, _ := initEngine(t, engine, b)
+
+			skipUnsupported(t, e)
+
+			t.Run("MissingKey", func(t *testing.T) {
+				_, errE := e.Get([]byte("missing key"))
+				if !errors.Is(errE, ErrNotFound) {
+					t.Fatalf("expected not found error, got: %v", errE)
+				}
+			})
+
+			if _, ok := e.(syntheticEngine); ok {
+				t.Skip("engine does not store values")
+			}
+
+			t.Run("Overwrite", func(t *testing.T) {
+				key := []byte("overwrite")
+				for i, size := range []int{10, 1000, 1, 100} {
+					value := randomValue(int64(i), size)
+					setValue(t, e, key, value)
+					checkValue(t, e, key, value)
+				}
+			})
+
+			t.Run("EmptyValue", func(t *testing.T) {
+				key := []byte("empty")
+				setValue(t, e, key, []byte{})
+				checkValue(t, e, key, []byte{})
+			})
+
+			t.Run("LargeValue", func(t *testing.T) {
+				key := []byte("large")
+				value := randomValue(1, testLargeSize)
+				setValue(t, e, key, value)
+				checkValue(t, e, key, value)
+			})
+
+			t.Run("BinaryKeys", func(t *testing.T) {
+				keys := [][]byte{
+					{0},
+					{0, 0},
+					{0, 1},
+					{0xff},
+					{0xff, 0},
+					{'/'},
+					{'.', '.'},
+				}
+				// Keys are kept short because some engines limit key length,
+				// but together they cover all byte values.
+				for i := 0; i < 256; i += 32 {
+					key := []byte{}
+					for j := i; j < i+32; j++ {
+						key = append(key, byte(j))
+					}
+					keys = append(keys, key)
+				}
+				for i, key := range keys {
+					setValue(t, e, key, []byte(fmt.Sprintf("value %d", i)))
+				}
+				for i, key := range keys {
+					checkValue(t, e, key, []byte(fmt.Sprintf("value %d", i)))
+				}
+			})
+
+			t.Run("ConcurrentReaders", func(t *testing.T) {
+				const keysPerWriter = 50
+				key := func(j uint64) []byte {
+					return binary.BigEndian.AppendUint64([]byte("concurrent"), j)
+				}
+
+				written := []*atomic.Uint64{}
+				for i := 0; i < testWriters; i++ {
+					written = append(written, new(atomic.Uint64))
+				}
+				var writing atomic.Int64
+				writing.Store(testWriters)
+
+				g := errgroup.Group{}
+				for w := 0; w < testWriters; w++ {
+					w := w
+					g.Go(func() error {
+						defer writing.Add(-1)
+						for i := uint64(0); i < keysPerWriter; i++ {
+							j := i*testWriters + uint64(w)
+							errE := e.Set(key(j), randomValue(int64(j), testValueSize))
+							if errE != nil {
+								return errE
+							}
+							written[w].Add(1)
+						}
+						return nil
+					})
+				}
+				for r := 0; r < testReaders; r++ {
+					r := r
+					g.Go(func() error {
+						w := uint64(r % testWriters)
+						for i := uint64(0); writing.Load() > 0; i++ {
+							c := written[w].Load()
+							if c == 0 {
+								continue
+							}
+							j := (i%c)*testWriters + w
+							value, errE := getValue(e, key(j))
+							if errE != nil {
+								return errE
+							}
+							if !bytes.Equal(value, randomValue(int64(j), testValueSize)) {
+								return errors.Errorf("unexpected value for %d", j)
+							}
+						}
+						return nil
+					})
+				}
+				err := g.Wait()
+				if err != nil {
+					t.Fatalf("% -+#.1v", err)
+				}
+			})
+
+			t.Run("Sync", func(t *testing.T) {
+				setValue(t, e, []byte("sync"), []byte("sync"))
+				errE := e.Sync()
+				if errE != nil {
+					t.Fatalf("% -+#.1v", errE)
+				}
+			})
+		})
+	}
+}
+
+func TestEnginesReopen(t *testing.T) {
+	t.Parallel()
+
+	for _, engine := range engines {
+		engine := engine
+
+		t.Run(engine.Name(), func(t *testing.T) {
+			if isVolatileEngine(engine) {
+				t.Skip("engine does not persist data")
+			}
+
+			if !isPostgres(engine) {
+				t.Parallel()
+			}
+
+			b := testBenchmark(t, engine)
+			e, closeEngine := initEngine(t, engine, b)
+			skipUnsupported(t, e)
+
+			values := map[string][]byte{}
+			for i := 0; i < 100; i++ {
+				key := []byte(fmt.Sprintf("key %d", i))
+				values[string(key)] = randomValue(int64(i), i*100) //nolint:gomnd
+				setValue(t, e, key, values[string(key)])
+			}
+			closeEngine()
+
+			b.Existing = true
+			e, _ = initEngine(t, engine, b)
+			for key, value := range values {
+				checkValue(t, e, []byte(key), value)
+			}
+		})
+	}
+}
+
+func TestEnginesNotEmpty(t *testing.T) {
+	t.Parallel()
+
+	for _, engine := range engines {
+		engine := engine
+
+		if isPostgres(engine) || isVolatileEngine(engine) {
+			// Postgres and volatile engines do not use the data directory.
+			continue
+		}
+
+		t.Run(engine.Name(), func(t *testing.T) {
+			t.Parallel()
+
+			b := testBenchmark(t, engine)
+			err := os.WriteFile(path.Join(b.Data, "file"), []byte("data"), 0o600) //nolint:gomnd
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := newEngine(engine)
+			errE := e.Init(b, zerolog.Nop())
+			if errE == nil {
+				_ = e.Close()
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestPebbleDiskFull(t *testing.T) {
+	t.Parallel()
+
+	for _, durability := range []string{durabilityFsync, durabilityNone} {
+		durability := durability
+
+		t.Run(durability, func(t *testing.T) {
+			t.Parallel()
+
+			engine := &Pebble{} //nolint:exhaustruct
+			b := testBenchmark(t, engine)
+			b.Durability = durability
+			b.faults = newFaults(map[string]float64{faultENOSPC: 1}, 0)
+			e, closeEngine := initEngine(t, engine, b)
+
+			key := []byte("before")
+			value := randomValue(0, testValueSize)
+			setValue(t, e, key, value)
+
+			b.faults.enable()
+
+			// All writers have to fail with disk full instead of blocking or exiting the process.
+			done := make(chan error, 1)
+			go func() {
+				g := errgroup.Group{}
+				for w := 0; w < testWriters; w++ {
+					w := w
+					g.Go(func() error {
+						for i := 0; i < 100_000; i++ {
+							errE := e.Set(binary.BigEndian.AppendUint64(nil, uint64(i*testWriters+w)), value)
+							if errE != nil && isNoSpace(errE) {
+								return nil
+							} else if errE != nil {
+								return errE
+							}
+						}
+						return errors.New("disk did not become full")
+					})
+				}
+				done <- g.Wait()
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("% -+#.1v", err)
+				}
+			case <-time.After(time.Minute):
+				t.Fatal("writers are blocked")
+			}
+
+			errE := e.Set([]byte("after"), value)
+			if errE == nil || !isNoSpace(errE) {
+				t.Fatalf("expected disk full error, got: %v", errE)
+			}
+
+			checkValue(t, e, key, value)
+
+			closed := make(chan struct{})
+			go func() {
+				closeEngine()
+				close(closed)
+			}()
+			select {
+			case <-closed:
+			case <-time.After(time.Minute):
+				t.Fatal("closing is blocked")
+			}
+		})
+	}
+}
+
+func TestFaultsNotDiskFull(t *testing.T) {
+	t.Parallel()
+
+	f := newFaults(map[string]float64{faultENOSPC: 1}, 0)
+	f.enable()
+	_, err := f.write(io.Discard, []byte("data"))
+	if !isNoSpace(err) {
+		t.Fatalf("expected no space error, got: %v", err)
+	}
+	if isDiskFull(err) {
+		t.Fatal("injected fault reported as disk full")
+	}
+	// Also when the engine formats the error instead of wrapping it.
+	if isDiskFull(errors.New(err.Error())) {
+		t.Fatal("formatted injected fault reported as disk full")
+	}
+}
+
+func TestNull(t *testing.T) {
+	t.Parallel()
+
+	engine := &Null{} //nolint:exhaustruct
+	b := testBenchmark(t, engine)
+	b.Size = testValueSize
+	e, _ := initEngine(t, engine, b)
+
+	// Values larger than the configured size are synthesized as well.
+	for i, size := range []int{0, 1, testValueSize, 2 * testValueSize} {
+		key := []byte(fmt.Sprintf("key %d", i))
+		setValue(t, e, key, randomValue(int64(i), size))
+		value, errE := getValue(e, key)
+		if errE != nil {
+			t.Fatalf("% -+#.1v", errE)
+		}
+		if len(value) != size {
+			t.Fatalf("unexpected value length for key %q: got %d, expected %d", key, len(value), size)
+		}
+	}
+
+	_, errE := e.Get([]byte("missing key"))
+	if !errors.Is(errE, ErrNotFound) {
+		t.Fatalf("expected not found error, got: %v", errE)
+	}
+}
+
+func TestS3Reader(t *testing.T) {
+	t.Parallel()
+
+	engine := &S3{} //nolint:exhaustruct
+	b := testBenchmark(t, engine)
+	e, _ := initEngine(t, engine, b)
+
+	key := []byte("key")
+	value := randomValue(0, testValueSize)
+	setValue(t, e, key, value)
+
+	reader, errE := e.Get(key)
+	if errE != nil {
+		t.Fatalf("% -+#.1v", errE)
+	}
+	defer reader.Close()
+
+	for _, seek := range []struct {
+		offset int64
+		whence int
+		// Expected offset after seeking.
+		expected int64
+	}{
+		{0, io.SeekStart, 0},
+		{testValueSize / 2, io.SeekStart, testValueSize / 2},
+		{0, io.SeekStart, 0},
+		{-10, io.SeekEnd, testValueSize - 10},
+		{-testValueSize / 2, io.SeekCurrent, testValueSize / 2},
+	} {
+		offset, err := reader.Seek(seek.offset, seek.whence)
+		if err != nil {
+			t.Fatalf("% -+#.1v", err)
+		}
+		if offset != seek.expected {
+			t.Fatalf("unexpected offset: got %d, expected %d", offset, seek.expected)
+		}
+		// We read only a part so that the next seek does not start at the end.
+		buf := make([]byte, 10)
+		_, err = io.ReadFull(reader, buf)
+		if err != nil {
+			t.Fatalf("% -+#.1v", err)
+		}
+		if !bytes.Equal(buf, value[offset:offset+10]) {
+			t.Fatalf("unexpected data at offset %d", offset)
+		}
+	}
+
+	// Reading again from the start returns the whole value.
+	_, err := reader.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Fatalf("% -+#.1v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("% -+#.1v", err)
+	}
+	if !bytes.Equal(data, value) {
+		t.Fatalf("unexpected value: got %d bytes, expected %d bytes", len(data), len(value))
+	}
+}
+
+func TestRedisConfigDurability(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		config   map[string]string
+		expected string
+	}{
+		{map[string]string{}, durabilityNone},
+		{map[string]string{"appendonly": "no", "appendfsync": "always"}, durabilityNone},
+		{map[string]string{"appendonly": "yes", "appendfsync": "always"}, durabilityGroup},
+		{map[string]string{"appendonly": "yes", "appendfsync": "everysec"}, durabilityPeriodic},
+		{map[string]string{"appendonly": "yes", "appendfsync": "no"}, durabilityNone},
+	} {
+		durability := redisConfigDurability(tt.config)
+		if durability != tt.expected {
+			t.Errorf("unexpected durability for %v: got %s, expected %s", tt.config, durability, tt.expected)
+		}
+	}
+}
+
+func TestFSShardedMetrics(t *testing.T) {
+	t.Parallel()
+
+	engine := &FSSharded{} //nolint:exhaustruct
+	b := testBenchmark(t, engine)
+	b.FSShardLevels = 2
+	b.FSShardWidth = 2
+	e, _ := initEngine(t, engine, b)
+
+	sink := metrics.NewInmemSink(time.Hour, time.Hour)
+	cfg := metrics.DefaultConfig("test")
+	cfg.EnableHostname = false
+	cfg.EnableRuntimeMetrics = false
+	mtr, err := metrics.New(cfg, sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mtr.Shutdown()
+	e.(measuringEngine).setMetrics(mtr) //nolint:forcetypeassert
+
+	value := randomValue(0, testValueSize)
+	setValue(t, e, []byte("key"), value)
+	// Directories exist now.
+	setValue(t, e, []byte("key"), value)
+
+	count := 0
+	for _, interval := range sink.Data() {
+		for _, sample := range interval.Samples {
+			if sample.Name == "test.set.mkdir" {
+				count += sample.Count
+			}
+		}
+	}
+	if count != b.FSShardLevels {
+		t.Fatalf("unexpected number of set.mkdir samples: got %d, expected %d", count, b.FSShardLevels)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// How long to wait for the embedded server to become ready.
+const etcdStartTimeout = time.Minute
+
+// Additional space for the rest of the request besides the value.
+const etcdRequestOverhead = 1024 * 1024
+
+// Maximum size of the database suggested by etcd. Once reached, etcd rejects writes
+// (even if compaction freed space inside the database) which we treat as the disk being full.
+const etcdQuotaBackendBytes = 8 * 1024 * 1024 * 1024
+
+// Number of revisions to keep when compacting. We read only the latest one.
+const etcdCompactionRetention = "1"
+
+var _ Engine = (*Etcd)(nil)
+
+// Etcd starts an embedded single-node etcd server in the data directory
+// and stores values through its client API over gRPC.
+type Etcd struct {
+	server     *embed.Etcd
+	client     *clientv3.Client
+	dir        string
+	durability string
+}
+
+func (*Etcd) Version(_ *Benchmark) (string, errors.E) {
+	return getModuleVersion("go.etcd.io/etcd/server/v3")
+}
+
+func (e *Etcd) Close() errors.E {
+	err := e.client.Close()
+	e.server.Close()
+	return errors.WithStack(err)
+}
+
+func (e *Etcd) Sync() errors.E {
+	if syncsWrites(e.durability) {
+		return nil
+	}
+	// etcd does not provide a way to sync on demand.
+	return syncFS(e.dir)
+}
+
+func (e *Etcd) Durability() string {
+	return e.durability
+}
+
+func (e *Etcd) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	// Reads are linearizable by default, which goes through raft.
+	resp, err := e.client.Get(context.Background(), string(key))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, errors.WithStack(ErrNotFound)
+	}
+	return bytesReadSeekCloser(resp.Kvs[0].Value, func() error {
+		return nil
+	}), nil
+}
+
+func (e *Etcd) Init(benchmark *Benchmark, logger zerolog.Logger) errors.E {
+	err := os.MkdirAll(benchmark.Data, 0o700) //nolint:gomnd
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
+		return errors.New("data directory is not empty")
+	}
+
+	// We let the OS choose ports. Advertised addresses are not used with one node.
+	local := url.URL{Scheme: "http", Host: "127.0.0.1:0"} //nolint:exhaustruct
+	config := embed.NewConfig()
+	config.Dir = benchmark.Data
+	config.ListenClientUrls = []url.URL{local}
+	config.AdvertiseClientUrls = []url.URL{local}
+	config.ListenPeerUrls = []url.URL{local}
+	config.AdvertisePeerUrls = []url.URL{local}
+	config.InitialCluster = config.InitialClusterFromName(config.Name)
+	// etcd logs a lot at the info level (e.g., for every compaction).
+	zapLogger := zap.New(zapCore{logger}, zap.IncreaseLevel(zapcore.WarnLevel))
+	config.ZapLoggerBuilder = embed.NewZapLoggerBuilder(zapLogger)
+	config.MaxRequestBytes = uint(benchmark.Size) + etcdRequestOverhead
+	config.QuotaBackendBytes = etcdQuotaBackendBytes
+	// Without compaction etcd keeps all previous values of keys.
+	config.AutoCompactionMode = embed.CompactorModeRevision
+	config.AutoCompactionRetention = etcdCompactionRetention
+	if syncsWrites(benchmark.Durability) {
+		// etcd syncs WAL once for all entries committed together.
+		e.durability = durabilityGroup
+	} else {
+		config.UnsafeNoFsync = true
+		e.durability = benchmark.Durability
+	}
+
+	server, err := embed.StartEtcd(config)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	select {
+	case <-server.Server.ReadyNotify():
+	case err := <-server.Err():
+		server.Close()
+		return errors.WithStack(err)
+	case <-time.After(etcdStartTimeout):
+		server.Close()
+		return errors.New("etcd server did not become ready")
+	}
+
+	client, err := clientv3.New(clientv3.Config{ //nolint:exhaustruct
+		Endpoints:          []string{server.Clients[0].Addr().String()},
+		MaxCallSendMsgSize: int(config.MaxRequestBytes),
+		MaxCallRecvMsgSize: int(config.MaxRequestBytes),
+		Logger:             zapLogger,
+	})
+	if err != nil {
+		server.Close()
+		return errors.WithStack(err)
+	}
+
+	e.server = server
+	e.client = client
+	e.dir = benchmark.Data
+	return nil
+}
+
+func (*Etcd) Name() string {
+	return "etcd"
+}
+
+func (e *Etcd) Set(key []byte, value []byte) errors.E {
+	_, err := e.client.Put(context.Background(), string(key), string(value))
+	return errors.WithStack(err)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/pebble/vfs"
+	"gitlab.com/tozd/go/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Kinds of faults which can be injected.
+const (
+	faultENOSPC     = "enospc"
+	faultEIO        = "eio"
+	faultShortWrite = "short"
+	faultSlowSync   = "slow"
+)
+
+var faultKinds = []string{faultENOSPC, faultEIO, faultShortWrite, faultSlowSync} //nolint:gochecknoglobals
+
+// errFault is wrapped by all injected errors so that they are not mistaken for real ones.
+var errFault = errors.Base("injected fault") //nolint:gochecknoglobals
+
+// isFault returns true if err is an injected fault.
+func isFault(err error) bool {
+	// Some engines format errors instead of wrapping them.
+	return errors.Is(err, errFault) || strings.Contains(err.Error(), errFault.Error())
+}
+
+// faults injects errors and delays into file writes and syncs at configured rates.
+// Injection starts only once it is enabled so that engines can initialize.
+//
+// All methods can be called on a nil faults, in which case they just
+// pass calls through.
+type faults struct {
+	rates   map[string]float64
+	delay   time.Duration
+	enabled atomic.Bool
+
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newFaults(rates map[string]float64, delay time.Duration) *faults {
+	if len(rates) == 0 {
+		return nil
+	}
+	return &faults{
+		rates:   rates,
+		delay:   delay,
+		enabled: atomic.Bool{},
+		mu:      sync.Mutex{},
+		r:       rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+}
+
+func (f *faults) enable() {
+	if f == nil {
+		return
+	}
+	f.enabled.Store(true)
+}
+
+// inject returns true if a fault of the given kind should be injected.
+func (f *faults) inject(kind string) bool {
+	if f == nil || !f.enabled.Load() {
+		return false
+	}
+	rate := f.rates[kind]
+	if rate <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.r.Float64() < rate
+}
+
+// write writes p to w. It can fail without writing anything (ENOSPC or EIO)
+// or after writing only a prefix of p (short write).
+func (f *faults) write(w io.Writer, p []byte) (int, error) {
+	if f.inject(faultENOSPC) {
+		return 0, errors.Prefix(unix.ENOSPC, errFault)
+	}
+	if f.inject(faultEIO) {
+		return 0, errors.Prefix(unix.EIO, errFault)
+	}
+	if len(p) > 1 && f.inject(faultShortWrite) {
+		f.mu.Lock()
+		n := f.r.Intn(len(p))
+		f.mu.Unlock()
+		n, err := w.Write(p[:n])
+		if err != nil {
+			return n, err //nolint:wrapcheck
+		}
+		// Like write(2) which wrote only a part of data because the disk became full.
+		return n, errors.WithMessage(errors.Prefix(unix.ENOSPC, errFault), "short write")
+	}
+	return w.Write(p) //nolint:wrapcheck
+}
+
+// sync calls syncFn. It can be delayed or fail with EIO.
+func (f *faults) sync(syncFn func() error) error {
+	if f.inject(faultSlowSync) {
+		time.Sleep(f.delay)
+	}
+	if f.inject(faultEIO) {
+		return errors.Prefix(unix.EIO, errFault)
+	}
+	return syncFn()
+}
+
+// file returns a wrapper around file which injects faults into writes.
+func (f *faults) file(file *os.File) io.Writer {
+	if f == nil {
+		return file
+	}
+	return faultsWriter{f, file}
+}
+
+type faultsWriter struct {
+	faults *faults
+	w      io.Writer
+}
+
+func (w faultsWriter) Write(p []byte) (int, error) {
+	return w.faults.write(w.w, p)
+}
+
+var _ vfs.FS = (*faultsFS)(nil)
+
+// faultsFS is a Pebble file system which injects faults into sstables and WAL opened for writing.
+//
+// Failed flushes and compactions are reported as background errors and retried,
+// so sstables get all faults. Pebble cannot continue after a failed write to WAL
+// and we recover from it only when the disk is full, so WAL gets only ENOSPC.
+// Pebble exits the process after a failed write to MANIFEST, so it gets no faults.
+type faultsFS struct {
+	vfs.FS
+	faults *faults
+}
+
+func (f faultsFS) wrap(name string, file vfs.File, err error) (vfs.File, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasSuffix(name, ".sst"):
+		return faultsFile{file, f.faults}, nil
+	case strings.HasSuffix(name, ".log"):
+		return walFaultsFile{file, f.faults}, nil
+	default:
+		return file, nil
+	}
+}
+
+func (f faultsFS) Create(name string) (vfs.File, error) {
+	file, err := f.FS.Create(name)
+	return f.wrap(name, file, err)
+}
+
+func (f faultsFS) ReuseForWrite(oldname, newname string) (vfs.File, error) {
+	file, err := f.FS.ReuseForWrite(oldname, newname)
+	return f.wrap(newname, file, err)
+}
+
+var _ vfs.File = (*faultsFile)(nil)
+
+type faultsFile struct {
+	vfs.File
+	faults *faults
+}
+
+func (f faultsFile) Write(p []byte) (int, error) {
+	return f.faults.write(f.File, p)
+}
+
+func (f faultsFile) Sync() error {
+	return f.faults.sync(f.File.Sync)
+}
+
+func (f faultsFile) SyncData() error {
+	return f.faults.sync(f.File.SyncData)
+}
+
+func (f faultsFile) SyncTo(length int64) (bool, error) {
+	var fullSync bool
+	err := f.faults.sync(func() error {
+		var err error
+		fullSync, err = f.File.SyncTo(length)
+		return err //nolint:wrapcheck
+	})
+	return fullSync, err
+}
+
+var _ vfs.File = (*walFaultsFile)(nil)
+
+// walFaultsFile injects only ENOSPC into writes.
+type walFaultsFile struct {
+	vfs.File
+	faults *faults
+}
+
+func (f walFaultsFile) Write(p []byte) (int, error) {
+	if f.faults.inject(faultENOSPC) {
+		return 0, errors.Prefix(unix.ENOSPC, errFault)
+	}
+	return f.File.Write(p) //nolint:wrapcheck
+}
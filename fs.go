@@ -13,7 +13,9 @@ import (
 var _ Engine = (*FS)(nil)
 
 type FS struct {
-	dir string
+	dir        string
+	durability string
+	faults     *faults
 }
 
 func (*FS) Version(_ *Benchmark) (string, errors.E) {
@@ -26,8 +28,15 @@ func (e *FS) Close() errors.E {
 }
 
 func (e *FS) Sync() errors.E {
-	// We sync after every write, so there is nothing to sync here.
-	return nil
+	if syncsWrites(e.durability) {
+		// We sync after every write, so there is nothing to sync here.
+		return nil
+	}
+	return syncFS(e.dir)
+}
+
+func (e *FS) Durability() string {
+	return e.durability
 }
 
 func (e *FS) name(key []byte) string {
@@ -38,7 +47,9 @@ func (e *FS) Get(key []byte) (_ io.ReadSeekCloser, errE errors.E) { //nolint:non
 	name := e.name(key)
 
 	f, err := os.Open(path.Join(e.dir, name))
-	if err != nil {
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
@@ -50,10 +61,17 @@ func (e *FS) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
 	e.dir = benchmark.Data
+	e.faults = benchmark.faults
+	switch benchmark.Durability {
+	case durabilityNone, durabilityPeriodic, durabilityFdatasync:
+		e.durability = benchmark.Durability
+	default:
+		e.durability = durabilityFsync
+	}
 	return nil
 }
 
@@ -72,14 +90,20 @@ func (e *FS) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedret
 		errE = errors.Join(errE, f.Close())
 	}()
 
-	_, err = f.Write(value)
+	_, err = e.faults.file(f).Write(value)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	// To be able to compare between engines, we make all of them sync after every write.
-	// This lowers throughput, but it makes relative differences between engines clearer.
-	// TODO: Use fdatasync instead.
-	// TODO: Fsync parent directory as well.
-	return errors.WithStack(f.Sync())
+	if !syncsWrites(e.durability) {
+		return nil
+	}
+
+	errE = syncFile(f, e.durability, e.faults)
+	if errE != nil {
+		return errE
+	}
+
+	// The file might have been just created.
+	return syncDir(e.dir)
 }
@@ -15,7 +15,9 @@ import (
 var _ Engine = (*FSClone)(nil)
 
 type FSClone struct {
-	dir string
+	dir        string
+	durability string
+	faults     *faults
 }
 
 func (*FSClone) Version(_ *Benchmark) (string, errors.E) {
@@ -28,8 +30,15 @@ func (e *FSClone) Close() errors.E {
 }
 
 func (e *FSClone) Sync() errors.E {
-	// We sync after every write, so there is nothing to sync here.
-	return nil
+	if syncsWrites(e.durability) {
+		// We sync after every write, so there is nothing to sync here.
+		return nil
+	}
+	return syncFS(e.dir)
+}
+
+func (e *FSClone) Durability() string {
+	return e.durability
 }
 
 func (e *FSClone) name(key []byte) string {
@@ -40,7 +49,9 @@ func (e *FSClone) Get(key []byte) (_ io.ReadSeekCloser, errE errors.E) { //nolin
 	name := e.name(key)
 
 	f, err := os.Open(path.Join(e.dir, name))
-	if err != nil {
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	// We can use defer here because f is used only until this function returns.
@@ -88,10 +99,17 @@ func (e *FSClone) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
 	e.dir = benchmark.Data
+	e.faults = benchmark.faults
+	switch benchmark.Durability {
+	case durabilityNone, durabilityPeriodic, durabilityFdatasync:
+		e.durability = benchmark.Durability
+	default:
+		e.durability = durabilityFsync
+	}
 	return nil
 }
 
@@ -111,6 +129,10 @@ func (e *FSClone) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonam
 		if errE == nil && errE2 == nil {
 			// There was no error, we atomically (on Unix) rename the file to final filename.
 			errE3 = errors.WithStack(os.Rename(f.Name(), path.Join(e.dir, name)))
+			if errE3 == nil && syncsWrites(e.durability) {
+				// The rename is durable only once the directory is synced.
+				errE3 = syncDir(e.dir)
+			}
 		}
 		if errE != nil || errE2 != nil || errE3 != nil {
 			// There was an error, we remove the file.
@@ -118,14 +140,14 @@ func (e *FSClone) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonam
 		}
 	}()
 
-	_, err = f.Write(value)
+	_, err = e.faults.file(f).Write(value)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	// To be able to compare between engines, we make all of them sync after every write.
-	// This lowers throughput, but it makes relative differences between engines clearer.
-	// TODO: Use fdatasync instead.
-	// TODO: Fsync parent directory as well.
-	return errors.WithStack(f.Sync())
+	if !syncsWrites(e.durability) {
+		return nil
+	}
+
+	return syncFile(f, e.durability, e.faults)
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path"
+	"unsafe"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Alignment of buffers, offsets and sizes for O_DIRECT. It is a multiple
+// of logical block sizes of common disks.
+const fsDirectAlignment = 4096
+
+// Size of buffers used to write and read files in chunks.
+const fsDirectChunkSize = 1024 * 1024
+
+var _ Engine = (*FSDirect)(nil)
+
+// FSDirect is the same as FS, but writes and reads files with O_DIRECT,
+// bypassing the page cache.
+type FSDirect struct {
+	FS
+}
+
+// alignedBuffer returns a buffer of size rounded up to the alignment
+// whose memory address is aligned as well.
+func alignedBuffer(size int) []byte {
+	size = alignUp(size)
+	buf := make([]byte, size+fsDirectAlignment)
+	offset := 0
+	if remainder := int(uintptr(unsafe.Pointer(&buf[0])) % fsDirectAlignment); remainder != 0 { //nolint:gosec
+		offset = fsDirectAlignment - remainder
+	}
+	return buf[offset : offset+size]
+}
+
+func alignUp(size int) int {
+	return (size + fsDirectAlignment - 1) / fsDirectAlignment * fsDirectAlignment
+}
+
+func (e *FSDirect) Get(key []byte) (_ io.ReadSeekCloser, errE errors.E) { //nolint:nonamedreturns
+	name := e.name(key)
+
+	f, err := os.OpenFile(path.Join(e.dir, name), os.O_RDONLY|unix.O_DIRECT, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.Join(err, f.Close())
+	}
+
+	return &directReader{
+		file:     f,
+		size:     info.Size(),
+		offset:   0,
+		buf:      nil,
+		bufStart: 0,
+		bufLen:   0,
+	}, nil
+}
+
+func (e *FSDirect) Init(benchmark *Benchmark, logger zerolog.Logger) errors.E {
+	errE := e.FS.Init(benchmark, logger)
+	if errE != nil {
+		return errE
+	}
+
+	// Not all file systems support O_DIRECT (e.g., tmpfs), so we check early.
+	probe := path.Join(e.dir, ".fsdirect")
+	f, err := os.OpenFile(probe, os.O_WRONLY|os.O_CREATE|unix.O_DIRECT, 0o600) //nolint:gomnd
+	if errors.Is(err, unix.EINVAL) {
+		return errors.WrapWith(err, errors.Base("file system does not support O_DIRECT"))
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.Join(f.Close(), os.Remove(probe))
+}
+
+func (*FSDirect) Name() string {
+	return "fsdirect"
+}
+
+func (e *FSDirect) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedreturns
+	name := e.name(key)
+
+	f, err := os.OpenFile(path.Join(e.dir, name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC|unix.O_DIRECT, 0o600) //nolint:gomnd
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		errE = errors.Join(errE, f.Close())
+	}()
+
+	// With O_DIRECT, we can write only whole aligned blocks, so we copy the value
+	// into an aligned buffer, pad the tail with zeros, and truncate the file afterwards.
+	buf := alignedBuffer(min(len(value), fsDirectChunkSize))
+	w := e.faults.file(f)
+	for written := 0; written < len(value); {
+		n := copy(buf, value[written:])
+		clear(buf[n:alignUp(n)])
+		_, err = w.Write(buf[:alignUp(n)])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		written += n
+	}
+
+	if len(value)%fsDirectAlignment != 0 {
+		err = f.Truncate(int64(len(value)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if !syncsWrites(e.durability) {
+		return nil
+	}
+
+	// O_DIRECT bypasses the page cache, but it does not sync
+	// file metadata nor the disk's write cache.
+	errE = syncFile(f, e.durability, e.faults)
+	if errE != nil {
+		return errE
+	}
+
+	// The file might have been just created.
+	return syncDir(e.dir)
+}
+
+var _ io.ReadSeekCloser = (*directReader)(nil)
+
+// directReader reads the file opened with O_DIRECT in aligned chunks.
+type directReader struct {
+	file   *os.File
+	size   int64
+	offset int64
+	buf    []byte
+	// File offset and length of data in buf.
+	bufStart int64
+	bufLen   int
+}
+
+func (r *directReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.offset < r.bufStart || r.offset >= r.bufStart+int64(r.bufLen) {
+		if r.buf == nil {
+			r.buf = alignedBuffer(min(int(r.size), fsDirectChunkSize))
+		}
+		// Offsets have to be aligned as well. Reading the tail returns less than the buffer size.
+		r.bufStart = r.offset / fsDirectAlignment * fsDirectAlignment
+		n, err := r.file.ReadAt(r.buf, r.bufStart)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, errors.WithStack(err)
+		}
+		r.bufLen = n
+		if r.offset >= r.bufStart+int64(r.bufLen) {
+			return 0, errors.WithStack(io.ErrUnexpectedEOF)
+		}
+	}
+	n := copy(p, r.buf[r.offset-r.bufStart:r.bufLen])
+	r.offset += int64(n)
+	return n, nil
+}
+
+func (r *directReader) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = r.offset + offset
+	case io.SeekEnd:
+		next = r.size + offset
+	default:
+		return 0, errors.Errorf("invalid whence: %d", whence)
+	}
+	if next < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = next
+	return next, nil
+}
+
+func (r *directReader) Close() error {
+	return errors.WithStack(r.file.Close())
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/hashicorp/go-metrics"
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+var _ measuringEngine = (*FSSharded)(nil)
+
+// FSSharded is the same as FS, but stores files in a tree of directories
+// named after hex prefixes of the key hash, like blob stores do, instead of
+// storing all files in one directory.
+type FSSharded struct {
+	FS
+
+	levels int
+	width  int
+	// Records time spent creating each directory (and syncing its parent) as a "set.mkdir" sample.
+	mtr *metrics.Metrics
+}
+
+func (e *FSSharded) setMetrics(mtr *metrics.Metrics) {
+	e.mtr = mtr
+}
+
+// shards returns names of directories on the path to the file for the key.
+func (e *FSSharded) shards(key []byte) []string {
+	hash := sha256.Sum256(key)
+	prefix := hex.EncodeToString(hash[:])
+	shards := make([]string, e.levels)
+	for l := range shards {
+		shards[l] = prefix[l*e.width : (l+1)*e.width]
+	}
+	return shards
+}
+
+// path returns the directory and the file path for the key.
+func (e *FSSharded) path(key []byte) (string, string) {
+	dir := path.Join(append([]string{e.dir}, e.shards(key)...)...)
+	return dir, path.Join(dir, e.name(key))
+}
+
+func (e *FSSharded) Get(key []byte) (_ io.ReadSeekCloser, errE errors.E) { //nolint:nonamedreturns
+	_, name := e.path(key)
+
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return newReadSeekCloser(f, f.Close), nil
+}
+
+func (e *FSSharded) Init(benchmark *Benchmark, logger zerolog.Logger) errors.E {
+	errE := e.FS.Init(benchmark, logger)
+	if errE != nil {
+		return errE
+	}
+	e.levels = benchmark.FSShardLevels
+	e.width = benchmark.FSShardWidth
+	e.mtr = nil
+	return nil
+}
+
+func (*FSSharded) Name() string {
+	return "fssharded"
+}
+
+// mkdirs creates missing directories on the path to the file for the key.
+func (e *FSSharded) mkdirs(key []byte) errors.E {
+	parent := e.dir
+	for _, name := range e.shards(key) {
+		start := time.Now()
+		current := path.Join(parent, name)
+		err := os.Mkdir(current, 0o700) //nolint:gomnd
+		if errors.Is(err, os.ErrExist) {
+			// Another writer might have created it concurrently.
+			parent = current
+			continue
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+		if syncsWrites(e.durability) {
+			// The directory has just been created.
+			errE := syncDir(parent)
+			if errE != nil {
+				return errE
+			}
+		}
+		// Metrics are not set while the engine is being tested.
+		if e.mtr != nil {
+			e.mtr.MeasureSince([]string{"set", "mkdir"}, start)
+		}
+		parent = current
+	}
+	return nil
+}
+
+func (e *FSSharded) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedreturns
+	dir, name := e.path(key)
+
+	f, err := os.Create(name)
+	if errors.Is(err, os.ErrNotExist) {
+		errE := e.mkdirs(key)
+		if errE != nil {
+			return errE
+		}
+		f, err = os.Create(name)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		errE = errors.Join(errE, f.Close())
+	}()
+
+	_, err = e.faults.file(f).Write(value)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if !syncsWrites(e.durability) {
+		return nil
+	}
+
+	errE = syncFile(f, e.durability, e.faults)
+	if errE != nil {
+		return errE
+	}
+
+	// The file might have been just created.
+	return syncDir(dir)
+}
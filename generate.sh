@@ -6,7 +6,7 @@ for READERS in 1 50 100 ; do
   for WRITERS in 1 10 50 ; do
     for SIZE in 100B 32KB 5MB 500MB ; do
       for FS in ext4 xfs ; do
-        for ENGINE in badger bbolt bitcask buntdb fs fsclone immudb nutsdb pebble sqlite ; do
+        for ENGINE in badger bbolt bitcask buntdb fs fsclone fsdirect fssharded immudb leveldb lmdb nutsdb pebble s3 sqlite sqlitego sqliteinline ; do
           NAME="$ENGINE [$READERS $WRITERS $SIZE $FS]"
           sed \
             -e "s/__NAME__/${NAME}/g" \
@@ -32,3 +32,61 @@ for READERS in 1 50 100 ; do
     done
   done
 done
+
+# Redis keeps all data in memory, so it runs only with small values. Its AOF is
+# stored in the service container and not on the runner's file system, so the
+# file system does not matter.
+for READERS in 1 50 100 ; do
+  for WRITERS in 1 10 50 ; do
+    for SIZE in 100B 32KB ; do
+      NAME="redis [$READERS $WRITERS $SIZE]"
+      sed \
+        -e "s/__NAME__/${NAME}/g" \
+        -e "s/__READERS__/${READERS}/g" \
+        -e "s/__WRITERS__/${WRITERS}/g" \
+        -e "s/__SIZE__/${SIZE}/g" \
+        -e "s/__FS__/ext4/g" \
+        -e "s/__ENGINE__/redis/g" \
+        .gitlab-ci-template-entry-redis.yml >> generated-gitlab-ci.yml
+    done
+  done
+done
+
+# etcd limits the size of its database and requests, so it runs only with small values.
+for READERS in 1 50 100 ; do
+  for WRITERS in 1 10 50 ; do
+    for SIZE in 100B 32KB ; do
+      for FS in ext4 xfs ; do
+        NAME="etcd [$READERS $WRITERS $SIZE $FS]"
+        sed \
+          -e "s/__NAME__/${NAME}/g" \
+          -e "s/__READERS__/${READERS}/g" \
+          -e "s/__WRITERS__/${WRITERS}/g" \
+          -e "s/__SIZE__/${SIZE}/g" \
+          -e "s/__FS__/${FS}/g" \
+          -e "s/__ENGINE__/etcd/g" \
+          .gitlab-ci-template-entry.yml >> generated-gitlab-ci.yml
+      done
+    done
+  done
+done
+
+# Baselines keep data only in memory, so the file system does not matter
+# and only small values fit into memory for the whole run.
+for READERS in 1 50 100 ; do
+  for WRITERS in 1 10 50 ; do
+    for SIZE in 100B 32KB ; do
+      for ENGINE in memory null ; do
+        NAME="$ENGINE [$READERS $WRITERS $SIZE]"
+        sed \
+          -e "s/__NAME__/${NAME}/g" \
+          -e "s/__READERS__/${READERS}/g" \
+          -e "s/__WRITERS__/${WRITERS}/g" \
+          -e "s/__SIZE__/${SIZE}/g" \
+          -e "s/__FS__/ext4/g" \
+          -e "s/__ENGINE__/${ENGINE}/g" \
+          .gitlab-ci-template-entry.yml >> generated-gitlab-ci.yml
+      done
+    done
+  done
+done
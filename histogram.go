@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+)
+
+const (
+	// Latencies are bucketed logarithmically with this many buckets per doubling,
+	// which gives us around 9% resolution. The first bucket holds everything
+	// under one microsecond.
+	histogramBucketsPerDoubling = 8
+	// This covers latencies up to 2^40 microseconds (around 12 days).
+	histogramBuckets = 40*histogramBucketsPerDoubling + 1
+)
+
+// histogram collects latency samples (in milliseconds, as reported by go-metrics)
+// so that we can compute percentiles without retaining all samples.
+type histogram struct {
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+	Min    float64
+	Max    float64
+}
+
+func newHistogram() *histogram {
+	return &histogram{
+		Counts: make([]uint64, histogramBuckets),
+		Count:  0,
+		Sum:    0,
+		Min:    math.Inf(1),
+		Max:    math.Inf(-1),
+	}
+}
+
+func histogramBucket(value float64) int {
+	us := value * 1000 //nolint:gomnd
+	if us < 1 {
+		return 0
+	}
+	i := int(math.Log2(us)*histogramBucketsPerDoubling) + 1
+	if i >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return i
+}
+
+// histogramBucketBound returns the upper bound of the bucket i (in milliseconds).
+func histogramBucketBound(i int) float64 {
+	return math.Exp2(float64(i)/histogramBucketsPerDoubling) / 1000 //nolint:gomnd
+}
+
+func (h *histogram) add(value float64) {
+	h.Counts[histogramBucket(value)]++
+	h.Count++
+	h.Sum += value
+	h.Min = math.Min(h.Min, value)
+	h.Max = math.Max(h.Max, value)
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+	h.Min = math.Min(h.Min, other.Min)
+	h.Max = math.Max(h.Max, other.Max)
+}
+
+func (h *histogram) mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+// quantile returns an approximation of the q-quantile, q being on interval [0, 1].
+func (h *histogram) quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	if rank == 0 {
+		rank = 1
+	}
+	seen := uint64(0)
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank {
+			// Bucket bound is only an approximation, but we know the value
+			// cannot be outside of the observed range.
+			return math.Max(h.Min, math.Min(h.Max, histogramBucketBound(i)))
+		}
+	}
+	return h.Max
+}
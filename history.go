@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"crawshaw.io/sqlite"
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/c2h5oh/datasize"
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+const resultsSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	timestamp TEXT NOT NULL,
+	engine TEXT NOT NULL,
+	engine_version TEXT NOT NULL,
+	writers INTEGER NOT NULL,
+	readers INTEGER NOT NULL,
+	size INTEGER NOT NULL,
+	vary INTEGER NOT NULL,
+	threads INTEGER NOT NULL,
+	threads_multiplier REAL NOT NULL,
+	memory INTEGER NOT NULL,
+	cpu TEXT NOT NULL,
+	cores INTEGER NOT NULL,
+	go_runtime TEXT NOT NULL,
+	go_compile TEXT NOT NULL,
+	fs TEXT NOT NULL,
+	duration INTEGER NOT NULL,
+	durability TEXT NOT NULL DEFAULT '',
+	max_keys INTEGER NOT NULL DEFAULT 0,
+	max_data INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS runs_engine_timestamp ON runs (engine, timestamp);
+CREATE TABLE IF NOT EXISTS operations (
+	run INTEGER NOT NULL REFERENCES runs (id),
+	name TEXT NOT NULL,
+	count INTEGER NOT NULL,
+	errors INTEGER NOT NULL DEFAULT 0,
+	rate REAL NOT NULL,
+	throughput REAL NOT NULL,
+	min REAL NOT NULL,
+	max REAL NOT NULL,
+	mean REAL NOT NULL,
+	p50 REAL NOT NULL,
+	p90 REAL NOT NULL,
+	p99 REAL NOT NULL,
+	PRIMARY KEY (run, name)
+);
+`
+
+// resultsColumns are columns added to the results database after it was first released.
+// They are added to existing databases when opening them for writing.
+var resultsColumns = []struct { //nolint:gochecknoglobals
+	table      string
+	name       string
+	definition string
+}{
+	{"runs", "durability", "TEXT NOT NULL DEFAULT ''"},
+	{"runs", "max_keys", "INTEGER NOT NULL DEFAULT 0"},
+	{"runs", "max_data", "INTEGER NOT NULL DEFAULT 0"},
+	{"operations", "errors", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// migrateResults adds to the results database columns it is missing.
+func migrateResults(conn *sqlite.Conn) errors.E {
+	for _, column := range resultsColumns {
+		exists := false
+		err := sqlitex.Exec(conn, `SELECT 1 FROM pragma_table_info(?) WHERE name = ?`, func(_ *sqlite.Stmt) error {
+			exists = true
+			return nil
+		}, column.table, column.name)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if exists {
+			continue
+		}
+		err = sqlitex.ExecTransient(conn, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, column.table, column.name, column.definition), nil)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// openResults opens the results database at path. When readOnly is true, the database
+// must already exist, otherwise it is created if it does not yet exist.
+func openResults(path string, readOnly bool) (*sqlite.Conn, errors.E) {
+	if readOnly {
+		conn, err := sqlite.OpenConn(path, sqlite.SQLITE_OPEN_READONLY|sqlite.SQLITE_OPEN_WAL)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return conn, nil
+	}
+	conn, err := sqlite.OpenConn(path, sqlite.SQLITE_OPEN_READWRITE|sqlite.SQLITE_OPEN_CREATE|sqlite.SQLITE_OPEN_WAL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = sqlitex.ExecScript(conn, resultsSchema)
+	if err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+	errE := migrateResults(conn)
+	if errE != nil {
+		return nil, errors.Join(errE, conn.Close())
+	}
+	return conn, nil
+}
+
+// saveResults appends results to the results database at path,
+// creating the database if it does not yet exist.
+func saveResults(path string, results *Results) (errE errors.E) { //nolint:nonamedreturns
+	conn, errE := openResults(path, false)
+	if errE != nil {
+		return errE
+	}
+	defer func() {
+		errE = errors.Join(errE, conn.Close())
+	}()
+
+	tx := sqlitex.Save(conn)
+	defer func() {
+		// Calling "tx" already joins errors.
+		var err error = errE
+		tx(&err)
+		errE = errors.WithStack(err)
+	}()
+
+	err := sqlitex.Exec(conn, `INSERT INTO runs (
+		timestamp, engine, engine_version, writers, readers, size, vary, threads, threads_multiplier,
+		memory, cpu, cores, go_runtime, go_compile, fs, duration, durability, max_keys, max_data
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, nil,
+		results.Timestamp.UTC().Format(time.RFC3339Nano), results.Engine, results.EngineVersion,
+		results.Writers, results.Readers, results.Size, results.Vary, results.Threads, results.ThreadsMultiplier,
+		results.Memory, results.CPU, results.Cores, results.GoRuntime, results.GoCompile, results.FS,
+		results.Duration.Nanoseconds(), results.Durability, results.MaxKeys, results.MaxData,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	run := conn.LastInsertRowID()
+	for name, op := range results.Operations {
+		err = sqlitex.Exec(conn, `INSERT INTO operations (
+			run, name, count, errors, rate, throughput, min, max, mean, p50, p90, p99
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, nil,
+			run, name, op.Count, op.Errors, op.Rate, op.Throughput, op.Min, op.Max, op.Mean, op.P50, op.P90, op.P99,
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+//nolint:lll
+type History struct {
+	Engine    string            `arg:""                         enum:"${engines}"                 help:"Engine to show history for. Possible: ${engines}."                                                                                                         required:""`
+	Results   string            `       default:"results.db"                      env:"RESULTS"   help:"Results database to query. Default: ${default}. Environment variable: ${env}."                                                          placeholder:"PATH"             short:"R"`
+	Readers   int               `                                                 env:"READERS"   help:"Show only runs with this number of concurrent readers. Environment variable: ${env}."                                                   placeholder:"INT"              short:"r"`
+	Writers   int               `                                                 env:"WRITERS"   help:"Show only runs with this number of concurrent writers. Environment variable: ${env}."                                                   placeholder:"INT"              short:"w"`
+	Size      datasize.ByteSize `                                                 env:"SIZE"      help:"Show only runs with this size of values. Environment variable: ${env}."                                                                 placeholder:"SIZE"             short:"s"`
+	FS        string            `                                                 env:"FS"        help:"Show only runs on this file system. Environment variable: ${env}."                                                                      placeholder:"FS"`
+	Operation []string          `       default:"set,get.total"                   env:"OPERATION" help:"Operations to show. Possible: set,get.ready,get.first,get.total,get.miss,set.mkdir. Default: ${default}. Environment variable: ${env}." placeholder:"NAME"`
+}
+
+// historyScenario identifies runs which are comparable between each other.
+type historyScenario struct {
+	Readers    int
+	Writers    int
+	Size       uint64
+	Vary       bool
+	FS         string
+	Durability string
+	MaxKeys    uint64
+	MaxData    uint64
+	Name       string
+}
+
+func (h *History) Run(logger zerolog.Logger) (errE errors.E) { //nolint:nonamedreturns
+	conn, errE := openResults(h.Results, true)
+	if errE != nil {
+		return errE
+	}
+	defer func() {
+		errE = errors.Join(errE, conn.Close())
+	}()
+
+	// Previous rate for each scenario, so that we can report the change.
+	previous := map[historyScenario]float64{}
+
+	err := sqlitex.Exec(conn, `SELECT
+			r.timestamp, r.engine_version, r.readers, r.writers, r.size, r.vary, r.fs, r.durability, r.max_keys, r.max_data,
+			r.cpu, r.go_runtime, o.name, o.count, o.errors, o.rate, o.throughput, o.mean, o.p50, o.p90, o.p99
+		FROM runs r JOIN operations o ON (o.run = r.id)
+		WHERE r.engine = ? AND (? = 0 OR r.readers = ?) AND (? = 0 OR r.writers = ?) AND (? = 0 OR r.size = ?) AND (? = '' OR r.fs = ?)
+		ORDER BY r.timestamp, r.id, o.name`,
+		func(stmt *sqlite.Stmt) error {
+			scenario := historyScenario{
+				Readers:    int(stmt.GetInt64("readers")),
+				Writers:    int(stmt.GetInt64("writers")),
+				Size:       uint64(stmt.GetInt64("size")),
+				Vary:       stmt.GetInt64("vary") != 0,
+				FS:         stmt.GetText("fs"),
+				Durability: stmt.GetText("durability"),
+				MaxKeys:    uint64(stmt.GetInt64("max_keys")),
+				MaxData:    uint64(stmt.GetInt64("max_data")),
+				Name:       stmt.GetText("name"),
+			}
+			if !slices.Contains(h.Operation, scenario.Name) {
+				return nil
+			}
+			rate := stmt.GetFloat("rate")
+			e := logger.Info().Str("timestamp", stmt.GetText("timestamp")).Str("engineVersion", stmt.GetText("engine_version")).
+				Int("readers", scenario.Readers).Int("writers", scenario.Writers).Uint64("size", scenario.Size).
+				Bool("vary", scenario.Vary).Str("fs", scenario.FS).Str("durability", scenario.Durability).
+				Uint64("maxKeys", scenario.MaxKeys).Uint64("maxData", scenario.MaxData).Str("cpu", stmt.GetText("cpu")).
+				Str("goRuntime", stmt.GetText("go_runtime")).Int64("count", stmt.GetInt64("count")).Int64("errors", stmt.GetInt64("errors")).
+				Float64("rate", rate).Float64("throughput", stmt.GetFloat("throughput")).Float64("mean", stmt.GetFloat("mean")).
+				Float64("p50", stmt.GetFloat("p50")).Float64("p90", stmt.GetFloat("p90")).Float64("p99", stmt.GetFloat("p99"))
+			if p, ok := previous[scenario]; ok && p != 0 {
+				// Relative change of the rate compared to the previous comparable run.
+				e = e.Float64("change", (rate-p)/p)
+			}
+			previous[scenario] = rate
+			e.Msgf("history %s", scenario.Name)
+			return nil
+		},
+		h.Engine, h.Readers, h.Readers, h.Writers, h.Writers, uint64(h.Size), uint64(h.Size), h.FS, h.FS,
+	)
+	return errors.WithStack(err)
+}
@@ -13,7 +13,8 @@ import (
 var _ Engine = (*Immudb)(nil)
 
 type Immudb struct {
-	db *store.ImmuStore
+	db         *store.ImmuStore
+	durability string
 }
 
 func (*Immudb) Version(_ *Benchmark) (string, errors.E) {
@@ -28,6 +29,10 @@ func (e *Immudb) Sync() errors.E {
 	return errors.WithStack(e.db.Sync())
 }
 
+func (e *Immudb) Durability() string {
+	return e.durability
+}
+
 func (e *Immudb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	tx, err := e.db.NewTx(context.Background(), store.DefaultTxOptions().WithMode(store.ReadOnlyTx))
 	if err != nil {
@@ -35,7 +40,9 @@ func (e *Immudb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	}
 
 	ref, err := tx.Get(context.Background(), key)
-	if err != nil {
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil, errors.Join(errors.WrapWith(err, ErrNotFound), tx.Cancel())
+	} else if err != nil {
 		return nil, errors.Join(err, tx.Cancel())
 	}
 	value, err := ref.Resolve()
@@ -50,13 +57,23 @@ func (e *Immudb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 func (e *Immudb) Init(benchmark *Benchmark, logger zerolog.Logger) errors.E {
 	// We set the max value to 6 GB so that we can test values larger than 2 GB.
 	maxValueLen := 6 * 1024 * 1024 * 1024 //nolint:gomnd
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
 	opts := store.DefaultOptions()
-	// To be able to compare between engines, we make all of them sync after every write.
-	// This lowers throughput, but it makes relative differences between engines clearer.
-	opts = opts.WithSyncFrequency(0)
+	switch benchmark.Durability {
+	case durabilityNone, durabilityPeriodic:
+		opts = opts.WithSynced(false)
+		e.durability = benchmark.Durability
+	case durabilityGroup:
+		// Immudb waits for concurrent commits up to the sync frequency and syncs them together.
+		opts = opts.WithSyncFrequency(store.DefaultSyncFrequency)
+		e.durability = durabilityGroup
+	default:
+		// Immudb always uses fsync.
+		opts = opts.WithSyncFrequency(0)
+		e.durability = durabilityFsync
+	}
 	opts = opts.WithCompressionFormat(appendable.NoCompression)
 	opts = opts.WithMaxValueLen(maxValueLen)
 	opts = opts.WithIndexOptions(store.DefaultIndexOptions().WithMaxActiveSnapshots(2 * benchmark.Readers * benchmark.Writers))
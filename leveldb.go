@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+
+	"github.com/rs/zerolog"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"gitlab.com/tozd/go/errors"
+)
+
+var _ Engine = (*LevelDB)(nil)
+
+type LevelDB struct {
+	db         *leveldb.DB
+	dir        string
+	durability string
+	writeOpts  *opt.WriteOptions
+}
+
+func (*LevelDB) Version(_ *Benchmark) (string, errors.E) {
+	return getModuleVersion("github.com/syndtr/goleveldb")
+}
+
+func (e *LevelDB) Close() errors.E {
+	return errors.WithStack(e.db.Close())
+}
+
+func (e *LevelDB) Sync() errors.E {
+	if syncsWrites(e.durability) {
+		return nil
+	}
+	// goleveldb does not provide a way to sync the journal on demand.
+	return syncFS(e.dir)
+}
+
+func (e *LevelDB) Durability() string {
+	return e.durability
+}
+
+func (e *LevelDB) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	tx, err := e.db.GetSnapshot()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	value, err := tx.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		tx.Release()
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
+		tx.Release()
+		return nil, errors.WithStack(err)
+	}
+	return bytesReadSeekCloser(value, func() error {
+		tx.Release()
+		return nil
+	}), nil
+}
+
+func (e *LevelDB) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
+		return errors.New("data directory is not empty")
+	}
+	if syncsWrites(benchmark.Durability) {
+		e.writeOpts = &opt.WriteOptions{Sync: true} //nolint:exhaustruct
+		// goleveldb merges only small concurrent writes, so we do not report group.
+		e.durability = durabilityFsync
+	} else {
+		e.writeOpts = &opt.WriteOptions{Sync: false} //nolint:exhaustruct
+		e.durability = benchmark.Durability
+	}
+	db, err := leveldb.OpenFile(benchmark.Data, &opt.Options{ //nolint:exhaustruct
+		ErrorIfExist: !benchmark.Existing,
+		// We disable compression so that measurements are comparable.
+		Compression: opt.NoCompression,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.db = db
+	e.dir = benchmark.Data
+	return nil
+}
+
+func (*LevelDB) Name() string {
+	return "leveldb"
+}
+
+func (e *LevelDB) Set(key []byte, value []byte) errors.E {
+	// Batch is not really a transaction, but close enough for our needs.
+	tx := new(leveldb.Batch)
+	tx.Put(key, value)
+	return errors.WithStack(e.db.Write(tx, e.writeOpts))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/PowerDNS/lmdb-go/lmdb"
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+// Map size used when none is configured.
+const lmdbMapSize = 1 << 40
+
+// LMDB's default number of reader slots.
+const lmdbMaxReaders = 126
+
+var _ Engine = (*LMDB)(nil)
+
+type LMDB struct {
+	env        *lmdb.Env
+	dbi        lmdb.DBI
+	durability string
+}
+
+func (*LMDB) Version(_ *Benchmark) (string, errors.E) {
+	major, minor, patch, _ := lmdb.Version()
+	v2, errE := getModuleVersion("github.com/PowerDNS/lmdb-go")
+	if errE != nil {
+		return "", errE
+	}
+	return fmt.Sprintf("%d.%d.%d/%s", major, minor, patch, v2), nil
+}
+
+func (e *LMDB) Close() errors.E {
+	return errors.WithStack(e.env.Close())
+}
+
+func (e *LMDB) Sync() errors.E {
+	return errors.WithStack(e.env.Sync(true))
+}
+
+func (e *LMDB) Durability() string {
+	return e.durability
+}
+
+func (e *LMDB) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	tx, err := e.env.BeginTxn(nil, lmdb.Readonly)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Values point directly into the memory map and are valid until the transaction ends.
+	tx.RawRead = true
+
+	value, err := tx.Get(e.dbi, key)
+	if lmdb.IsNotFound(err) {
+		tx.Abort()
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
+		tx.Abort()
+		return nil, errors.WithStack(err)
+	}
+	return bytesReadSeekCloser(value, func() error {
+		tx.Abort()
+		return nil
+	}), nil
+}
+
+func (e *LMDB) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	err := os.MkdirAll(benchmark.Data, 0o700) //nolint:gomnd
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
+		return errors.New("data directory is not empty")
+	}
+	// Transactions are not tied to threads because goroutines can move between them.
+	flags := uint(lmdb.NoTLS)
+	switch benchmark.Durability {
+	case durabilityNone, durabilityPeriodic:
+		flags |= lmdb.NoSync
+		e.durability = benchmark.Durability
+	default:
+		// LMDB uses fdatasync on Linux and has only one writer at a time.
+		e.durability = durabilityFdatasync
+	}
+	mapSize := int64(benchmark.LMDBMapSize)
+	if mapSize == 0 {
+		mapSize = lmdbMapSize
+	}
+	env, err := lmdb.NewEnv()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = env.SetMapSize(mapSize)
+	if err != nil {
+		return errors.Join(err, env.Close())
+	}
+	err = env.SetMaxReaders(lmdbMaxReaders + benchmark.Readers)
+	if err != nil {
+		return errors.Join(err, env.Close())
+	}
+	err = env.Open(benchmark.Data, flags, 0o600) //nolint:gomnd
+	if err != nil {
+		return errors.Join(err, env.Close())
+	}
+	err = env.Update(func(tx *lmdb.Txn) error {
+		var err error //nolint:govet
+		e.dbi, err = tx.OpenRoot(0)
+		return errors.WithStack(err)
+	})
+	if err != nil {
+		return errors.Join(err, env.Close())
+	}
+	e.env = env
+	return nil
+}
+
+func (*LMDB) Name() string {
+	return "lmdb"
+}
+
+func (e *LMDB) Set(key []byte, value []byte) errors.E {
+	return errors.WithStack(e.env.Update(func(tx *lmdb.Txn) error {
+		return errors.WithStack(tx.Put(e.dbi, key, value, 0))
+	}))
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog"
+	"go.uber.org/zap/zapcore"
 )
 
 type loggerWrapper struct {
@@ -48,3 +49,74 @@ func (l loggerWrapper) Fatalf(msg string, args ...interface{}) {
 	l.log(zerolog.FatalLevel, msg, args...)
 	os.Exit(1)
 }
+
+var _ zapcore.Core = (*zapCore)(nil)
+
+// zapCore writes zap log entries to a zerolog logger.
+type zapCore struct {
+	logger zerolog.Logger
+}
+
+// zapLevel maps zap levels to zerolog levels.
+func zapLevel(level zapcore.Level) zerolog.Level {
+	switch level { //nolint:exhaustive
+	case zapcore.DebugLevel:
+		return zerolog.DebugLevel
+	case zapcore.InfoLevel:
+		return zerolog.InfoLevel
+	case zapcore.WarnLevel:
+		return zerolog.WarnLevel
+	default:
+		// zap itself panics or exits the process after writing the entry, if needed.
+		return zerolog.ErrorLevel
+	}
+}
+
+func (c zapCore) Enabled(level zapcore.Level) bool {
+	return zapLevel(level) >= c.logger.GetLevel()
+}
+
+// zapFields converts zap fields to zerolog fields. Errors are returned
+// separately so that they are logged as errors and not as strings.
+func zapFields(fields []zapcore.Field) (map[string]interface{}, map[string]error) {
+	encoder := zapcore.NewMapObjectEncoder()
+	errs := map[string]error{}
+	for _, field := range fields {
+		if err, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType {
+			errs[field.Key] = err
+			continue
+		}
+		field.AddTo(encoder)
+	}
+	return encoder.Fields, errs
+}
+
+func (c zapCore) With(fields []zapcore.Field) zapcore.Core {
+	f, errs := zapFields(fields)
+	ctx := c.logger.With().Fields(f)
+	for key, err := range errs {
+		ctx = ctx.AnErr(key, err)
+	}
+	return zapCore{ctx.Logger()}
+}
+
+func (c zapCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c zapCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	f, errs := zapFields(fields)
+	event := c.logger.WithLevel(zapLevel(entry.Level)).Fields(f)
+	for key, err := range errs {
+		event = event.AnErr(key, err)
+	}
+	event.Msg(entry.Message)
+	return nil
+}
+
+func (zapCore) Sync() error {
+	return nil
+}
@@ -17,14 +17,25 @@ var engines = []Engine{
 	&Bbolt{},
 	&Bitcask{},
 	&Buntdb{},
+	&Etcd{},
 	&Immudb{},
 	&FS{},
 	&FSClone{},
+	&FSDirect{},
+	&FSSharded{},
+	&LevelDB{},
+	&LMDB{},
+	&Memory{},
 	&Nutsdb{},
+	&Null{},
 	&Pebble{},
 	&Postgres{},
 	&PostgresLO{},
+	&Redis{},
+	&S3{},
 	&Sqlite{},
+	&SqliteGo{},
+	&SqliteInline{},
 }
 
 var enginesMap = map[string]Engine{} //nolint:gochecknoglobals
@@ -32,7 +43,12 @@ var enginesMap = map[string]Engine{} //nolint:gochecknoglobals
 type App struct {
 	zerolog.LoggingConfig
 
-	Benchmark Benchmark `embed:""`
+	Benchmark Benchmark `cmd:"" default:"withargs" help:"Run the benchmark. This is the default command."`
+	History   History   `cmd:""                    help:"Show results history for an engine."`
+	Site      Site      `cmd:""                    help:"Build a static HTML site from log files of benchmark runs."`
+	Crashtest Crashtest `cmd:""                    help:"Kill writing process at random times and verify that acknowledged writes survived."`
+	Anomalies Anomalies `cmd:""                    help:"Overwrite keys while reading them and check that reads return complete values."`
+	Recovery  Recovery  `cmd:""                    help:"Measure time to open existing data until the first successful read."`
 }
 
 func main() {
@@ -47,6 +63,6 @@ func main() {
 	cli.Run(&app, kong.Vars{
 		"engines": strings.Join(names, ","),
 	}, func(ctx *kong.Context) errors.E {
-		return errors.WithStack(app.Benchmark.Run(app.Logger))
+		return errors.WithStack(ctx.Run(app.Logger))
 	})
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+var _ volatileEngine = (*Memory)(nil)
+
+// Memory stores values in a map. It is a baseline which shows the overhead
+// of the benchmark itself.
+type Memory struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+func (*Memory) Version(_ *Benchmark) (string, errors.E) {
+	return runtime.Version(), nil
+}
+
+func (e *Memory) Close() errors.E {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.values = nil
+	return nil
+}
+
+func (*Memory) Sync() errors.E {
+	return nil
+}
+
+func (*Memory) Durability() string {
+	return durabilityNone
+}
+
+func (e *Memory) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	e.mu.RLock()
+	value, ok := e.values[string(key)]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, errors.WithStack(ErrNotFound)
+	}
+	// Stored values are never modified, so they can be read without holding the lock.
+	return bytesReadSeekCloser(value, func() error {
+		return nil
+	}), nil
+}
+
+func (e *Memory) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	if benchmark.Existing {
+		return errors.New("existing data is not supported")
+	}
+	e.values = map[string][]byte{}
+	return nil
+}
+
+func (*Memory) volatile(_ *Benchmark) bool {
+	return true
+}
+
+func (*Memory) Name() string {
+	return "memory"
+}
+
+func (e *Memory) Set(key []byte, value []byte) errors.E {
+	// We copy the value because the caller might reuse it.
+	value = append([]byte{}, value...)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.values[string(key)] = value
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+var (
+	_ syntheticEngine = (*Null)(nil)
+	_ volatileEngine  = (*Null)(nil)
+)
+
+// Null discards values and remembers only their sizes. It returns synthetic
+// values of the right size. It is a baseline which shows the overhead
+// of the benchmark itself.
+type Null struct {
+	mu    sync.RWMutex
+	sizes map[string]int
+	// Synthetic values are taken from this buffer.
+	zeros []byte
+}
+
+func (*Null) Version(_ *Benchmark) (string, errors.E) {
+	return runtime.Version(), nil
+}
+
+func (e *Null) Close() errors.E {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sizes = nil
+	e.zeros = nil
+	return nil
+}
+
+func (*Null) Sync() errors.E {
+	return nil
+}
+
+func (*Null) Durability() string {
+	return durabilityNone
+}
+
+func (e *Null) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	e.mu.RLock()
+	size, ok := e.sizes[string(key)]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, errors.WithStack(ErrNotFound)
+	}
+	var value []byte
+	if size <= len(e.zeros) {
+		value = e.zeros[:size]
+	} else {
+		value = make([]byte, size)
+	}
+	return bytesReadSeekCloser(value, func() error {
+		return nil
+	}), nil
+}
+
+func (e *Null) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	if benchmark.Existing {
+		return errors.New("existing data is not supported")
+	}
+	e.sizes = map[string]int{}
+	e.zeros = make([]byte, benchmark.Size)
+	return nil
+}
+
+func (*Null) volatile(_ *Benchmark) bool {
+	return true
+}
+
+func (*Null) Name() string {
+	return "null"
+}
+
+func (e *Null) Set(key []byte, value []byte) errors.E {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sizes[string(key)] = len(value)
+	return nil
+}
+
+func (*Null) synthetic() {}
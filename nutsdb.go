@@ -14,7 +14,8 @@ var nutsdbBucketName = "data" //nolint:gochecknoglobals
 var _ Engine = (*Nutsdb)(nil)
 
 type Nutsdb struct {
-	db *nutsdb.DB
+	db         *nutsdb.DB
+	durability string
 }
 
 func (*Nutsdb) Version(_ *Benchmark) (string, errors.E) {
@@ -29,6 +30,10 @@ func (e *Nutsdb) Sync() errors.E {
 	return errors.WithStack(e.db.ActiveFile.Sync())
 }
 
+func (e *Nutsdb) Durability() string {
+	return e.durability
+}
+
 func (e *Nutsdb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	tx, err := e.db.Begin(false)
 	if err != nil {
@@ -36,7 +41,9 @@ func (e *Nutsdb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	}
 
 	value, err := tx.Get(nutsdbBucketName, key)
-	if err != nil {
+	if errors.Is(err, nutsdb.ErrKeyNotFound) || errors.Is(err, nutsdb.ErrNotFoundKey) {
+		return nil, errors.Join(errors.WrapWith(err, ErrNotFound), tx.Rollback())
+	} else if err != nil {
 		return nil, errors.Join(err, tx.Rollback())
 	}
 	return bytesReadSeekCloser(value, func() error {
@@ -45,10 +52,15 @@ func (e *Nutsdb) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 }
 
 func (e *Nutsdb) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
-	//
+	if syncsWrites(benchmark.Durability) {
+		// Nutsdb always uses fsync.
+		e.durability = durabilityFsync
+	} else {
+		e.durability = benchmark.Durability
+	}
 	db, err := nutsdb.Open(
 		nutsdb.DefaultOptions,
 		nutsdb.WithDir(benchmark.Data),
@@ -56,11 +68,15 @@ func (e *Nutsdb) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 		// Currently it is possible to store only math.MaxInt32 large values.
 		// See: https://github.com/nutsdb/nutsdb/issues/574
 		nutsdb.WithSegmentSize(2*math.MaxInt32), //nolint:gomnd
+		nutsdb.WithSyncEnable(syncsWrites(benchmark.Durability)),
 	)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 	err = db.Update(func(tx *nutsdb.Tx) error {
+		if tx.ExistBucket(nutsdb.DataStructureBTree, nutsdbBucketName) {
+			return nil
+		}
 		return errors.WithStack(tx.NewBucket(nutsdb.DataStructureBTree, nutsdbBucketName))
 	})
 	if err != nil {
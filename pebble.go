@@ -2,16 +2,60 @@ package main
 
 import (
 	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
 	"github.com/rs/zerolog"
 	"gitlab.com/tozd/go/errors"
 )
 
 var _ Engine = (*Pebble)(nil)
 
+// Pebble stores values in Pebble.
+//
+// Pebble cannot continue after a failed write to WAL and aborts the process.
+// Only when faults are injected into Pebble, we recover from such failures
+// (see commit) so that the benchmark can continue, at the cost of running
+// every commit in its own goroutine.
 type Pebble struct {
-	db *pebble.DB
+	db         *pebble.DB
+	durability string
+	writeOpts  *pebble.WriteOptions
+	logger     zerolog.Logger
+	// Set when faults are injected and we recover from failed writes to WAL.
+	recovering bool
+
+	// The first error after which Pebble cannot accept writes anymore. failedCh
+	// is closed when failed is set.
+	failedMu sync.Mutex
+	failedCh chan struct{}
+	failed   error
+	// Set if a commit panicked while holding Pebble's commit lock, after
+	// which further commits and closing the database block forever.
+	broken atomic.Bool
+	// Number of commits which have not returned. After a failure, they
+	// might never return (e.g., when stalled), holding the commit lock.
+	committing atomic.Int64
+}
+
+// pebbleFatal is the panic value raised by pebbleLogger.Fatalf.
+type pebbleFatal struct {
+	err error
+}
+
+// pebbleLogger is the same as loggerWrapper, but panics instead of exiting the process
+// on fatal errors so that we can recover from failed commits. Pebble calls Fatalf
+// from its background goroutines as well, where the panic still ends the process.
+type pebbleLogger struct {
+	loggerWrapper
+}
+
+func (l pebbleLogger) Fatalf(msg string, args ...interface{}) {
+	l.log(zerolog.ErrorLevel, msg, args...)
+	panic(pebbleFatal{errors.Errorf(msg, args...)})
 }
 
 func (*Pebble) Version(_ *Benchmark) (string, errors.E) {
@@ -19,11 +63,39 @@ func (*Pebble) Version(_ *Benchmark) (string, errors.E) {
 }
 
 func (e *Pebble) Close() errors.E {
-	return errors.WithStack(e.db.Close())
+	e.failedMu.Lock()
+	failed := e.failed
+	e.failedMu.Unlock()
+	if e.broken.Load() || (failed != nil && e.committing.Load() > 0) {
+		// Closing would wait for the commit lock which is never released.
+		e.logger.Warn().Msg("not closing database after a failed commit")
+		return nil
+	}
+	err := e.db.Close()
+	if err != nil && failed != nil {
+		// Pebble returns the error which has already failed writes.
+		e.logger.Warn().Err(err).Msg("closing database after a failure")
+		return nil
+	}
+	return errors.WithStack(err)
 }
 
 func (e *Pebble) Sync() errors.E {
-	return errors.WithStack(e.db.Flush())
+	// Writing an empty log record with sync syncs all previous writes to WAL.
+	if e.recovering {
+		return e.commit(func() error {
+			return e.db.LogData(nil, pebble.Sync)
+		})
+	}
+	errE := e.err()
+	if errE != nil {
+		return errE
+	}
+	return errors.WithStack(e.db.LogData(nil, pebble.Sync))
+}
+
+func (e *Pebble) Durability() string {
+	return e.durability
 }
 
 func (e *Pebble) Get(key []byte) (io.ReadSeekCloser, errors.E) {
@@ -31,7 +103,9 @@ func (e *Pebble) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	tx := e.db.NewSnapshot()
 
 	value, closer, err := tx.Get(key)
-	if err != nil {
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, errors.Join(errors.WrapWith(err, ErrNotFound), tx.Close())
+	} else if err != nil {
 		return nil, errors.Join(err, tx.Close())
 	}
 	return bytesReadSeekCloser(value, func() error {
@@ -40,19 +114,58 @@ func (e *Pebble) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 }
 
 func (e *Pebble) Init(benchmark *Benchmark, logger zerolog.Logger) errors.E {
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
-	db, err := pebble.Open(benchmark.Data, &pebble.Options{ //nolint:exhaustruct
+	opts := &pebble.Options{ //nolint:exhaustruct
 		// The newest format for the current version of Pebble.
 		FormatMajorVersion: pebble.FormatPrePebblev1MarkedCompacted,
-		ErrorIfExists:      true,
+		ErrorIfExists:      !benchmark.Existing,
 		Logger:             loggerWrapper{logger},
+		EventListener: &pebble.EventListener{ //nolint:exhaustruct
+			BackgroundError: func(err error) {
+				// Same as the default.
+				loggerWrapper{logger}.Infof("background error: %s", err)
+				// When flushes fail because the disk is full, Pebble does not fail writes but
+				// stalls them once memtables fill up, so we fail them ourselves.
+				if isNoSpace(err) {
+					e.fail(err)
+				}
+			},
+		},
 		Levels: []pebble.LevelOptions{{ //nolint:exhaustruct
 			// We disable compression so that measurements are comparable.
 			Compression: pebble.NoCompression,
 		}},
-	})
+	}
+	e.recovering = benchmark.faults != nil
+	if e.recovering {
+		opts.Logger = pebbleLogger{loggerWrapper{logger}}
+		opts.EventListener.WALCreated = func(info pebble.WALCreateInfo) {
+			// When switching to a new WAL fails, Pebble panics while holding
+			// its database lock, which blocks also reads, so we panic before
+			// that. We do not do so while opening, when the error is returned.
+			if info.Err != nil && e.db != nil {
+				panic(errors.WithStack(info.Err))
+			}
+		}
+		opts.FS = pebbleFS{faultsFS{vfs.Default, benchmark.faults}, e}
+	}
+	if syncsWrites(benchmark.Durability) {
+		e.writeOpts = pebble.Sync
+		// Pebble's commit pipeline syncs concurrent commits to WAL together.
+		e.durability = durabilityGroup
+	} else {
+		e.writeOpts = pebble.NoSync
+		e.durability = benchmark.Durability
+	}
+	e.db = nil
+	e.logger = logger
+	e.failedCh = make(chan struct{})
+	e.failed = nil
+	e.broken.Store(false)
+	e.committing.Store(0)
+	db, err := pebble.Open(benchmark.Data, opts)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -64,7 +177,90 @@ func (*Pebble) Name() string {
 	return "pebble"
 }
 
-func (e *Pebble) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedreturns
+// fail records the first error after which Pebble cannot accept writes anymore.
+func (e *Pebble) fail(err error) {
+	e.failedMu.Lock()
+	defer e.failedMu.Unlock()
+	if e.failed == nil {
+		e.failed = err
+		close(e.failedCh)
+	}
+}
+
+// err returns the error after which Pebble cannot accept writes anymore, if any.
+func (e *Pebble) err() errors.E {
+	e.failedMu.Lock()
+	defer e.failedMu.Unlock()
+	return errors.WithStack(e.failed)
+}
+
+// commit calls commitFn which writes to WAL. It is used only when faults are injected.
+//
+// Pebble cannot continue after a failed write to WAL: it calls Fatalf (which
+// panics, see pebbleLogger) or panics itself, sometimes while holding its commit
+// lock so that other commits wait for it forever. We recover the panic and fail
+// this and all other commits.
+func (e *Pebble) commit(commitFn func() error) errors.E {
+	errE := e.err()
+	if errE != nil {
+		return errE
+	}
+
+	done := make(chan errors.E, 1)
+	e.committing.Add(1)
+	go func() {
+		defer e.committing.Add(-1)
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			var err error
+			switch r := r.(type) {
+			case pebbleFatal:
+				err = r.err
+			case error:
+				err = r
+				e.broken.Store(true)
+			default:
+				panic(r)
+			}
+			e.fail(err)
+			done <- errors.WithStack(err)
+		}()
+		done <- errors.WithStack(commitFn())
+	}()
+
+	select {
+	case errE := <-done:
+		return errE
+	case <-e.failedCh:
+		// The commit might be blocked forever, so we do not wait for it,
+		// unless it has just finished.
+		select {
+		case errE := <-done:
+			return errE
+		default:
+		}
+		return e.err()
+	}
+}
+
+func (e *Pebble) Set(key []byte, value []byte) errors.E {
+	if e.recovering {
+		return e.commit(func() error {
+			return e.set(key, value)
+		})
+	}
+	// Fail fast once the disk is full, see BackgroundError.
+	errE := e.err()
+	if errE != nil {
+		return errE
+	}
+	return e.set(key, value)
+}
+
+func (e *Pebble) set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedreturns
 	// Batch is not really a transaction, but close enough for our needs.
 	// Maybe we should use instead e.db.NewSnapshot().NewIndexedBatch() once it is available.
 	// See: https://github.com/cockroachdb/pebble/issues/1416
@@ -77,12 +273,57 @@ func (e *Pebble) Set(key []byte, value []byte) (errE errors.E) { //nolint:noname
 		}
 	}()
 
-	// To be able to compare between engines, we make all of them sync after every write.
-	// This lowers throughput, but it makes relative differences between engines clearer.
-	err := tx.Set(key, value, pebble.Sync)
+	err := tx.Set(key, value, e.writeOpts)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	return errors.WithStack(tx.Commit(pebble.Sync))
+	return errors.WithStack(tx.Commit(e.writeOpts))
+}
+
+var _ vfs.FS = (*pebbleFS)(nil)
+
+// pebbleFS fails the engine on the first failed write to WAL.
+//
+// Pebble reports such failure only to commits which wait for WAL to be synced,
+// and otherwise only when it switches to a new WAL, while holding its locks.
+// So we fail further commits ourselves before that happens.
+type pebbleFS struct {
+	vfs.FS
+	engine *Pebble
+}
+
+func (f pebbleFS) wrap(name string, file vfs.File, err error) (vfs.File, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".log") {
+		return file, nil
+	}
+	return pebbleWALFile{file, f.engine}, nil
+}
+
+func (f pebbleFS) Create(name string) (vfs.File, error) {
+	file, err := f.FS.Create(name)
+	return f.wrap(name, file, err)
+}
+
+func (f pebbleFS) ReuseForWrite(oldname, newname string) (vfs.File, error) {
+	file, err := f.FS.ReuseForWrite(oldname, newname)
+	return f.wrap(newname, file, err)
+}
+
+var _ vfs.File = (*pebbleWALFile)(nil)
+
+type pebbleWALFile struct {
+	vfs.File
+	engine *Pebble
+}
+
+func (f pebbleWALFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	if err != nil {
+		f.engine.fail(err)
+	}
+	return n, err //nolint:wrapcheck
 }
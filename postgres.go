@@ -14,7 +14,8 @@ import (
 var _ Engine = (*Postgres)(nil)
 
 type Postgres struct {
-	dbpool *pgxpool.Pool
+	dbpool     *pgxpool.Pool
+	durability string
 }
 
 func (*Postgres) Version(benchmark *Benchmark) (string, errors.E) {
@@ -31,6 +32,10 @@ func (*Postgres) Sync() errors.E {
 	return nil
 }
 
+func (e *Postgres) Durability() string {
+	return e.durability
+}
+
 func (e *Postgres) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	ctx := context.Background()
 
@@ -44,7 +49,9 @@ func (e *Postgres) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 
 	var value []byte
 	err = tx.QueryRow(ctx, `SELECT value FROM kv WHERE key=$1`, key).Scan(&value)
-	if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.Join(errors.WrapWith(err, ErrNotFound), tx.Rollback(ctx))
+	} else if err != nil {
 		return nil, errors.Join(err, tx.Rollback(ctx))
 	}
 
@@ -56,12 +63,13 @@ func (e *Postgres) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 func (e *Postgres) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	ctx := context.Background()
 
-	dbpool, err := pgxpool.New(ctx, benchmark.Postgres)
-	if err != nil {
-		return errors.WithStack(err)
+	dbpool, durability, errE := postgresPool(benchmark)
+	if errE != nil {
+		return errE
 	}
+	e.durability = durability
 	var maxConnectionsStr string
-	err = dbpool.QueryRow(ctx, `SHOW max_connections`).Scan(&maxConnectionsStr)
+	err := dbpool.QueryRow(ctx, `SHOW max_connections`).Scan(&maxConnectionsStr)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -82,7 +90,12 @@ func (e *Postgres) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	if maxConnections-superuserReservedConnections < benchmark.Readers+benchmark.Writers+1 {
 		return errors.New("max_connections too low")
 	}
-	_, err = dbpool.Exec(ctx, `CREATE TABLE kv (key BYTEA PRIMARY KEY NOT NULL, value BYTEA NOT NULL)`)
+	// Without existing data, creating the table fails if it already exists.
+	createTable := `CREATE TABLE kv (key BYTEA PRIMARY KEY NOT NULL, value BYTEA NOT NULL)`
+	if benchmark.Existing {
+		createTable = `CREATE TABLE IF NOT EXISTS kv (key BYTEA PRIMARY KEY NOT NULL, value BYTEA NOT NULL)`
+	}
+	_, err = dbpool.Exec(ctx, createTable)
 	if err != nil {
 		return errors.WithStack(err)
 	}
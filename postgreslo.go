@@ -15,7 +15,8 @@ import (
 var _ Engine = (*PostgresLO)(nil)
 
 type PostgresLO struct {
-	dbpool *pgxpool.Pool
+	dbpool     *pgxpool.Pool
+	durability string
 }
 
 func (*PostgresLO) Version(benchmark *Benchmark) (string, errors.E) {
@@ -32,6 +33,10 @@ func (*PostgresLO) Sync() errors.E {
 	return nil
 }
 
+func (e *PostgresLO) Durability() string {
+	return e.durability
+}
+
 func (e *PostgresLO) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 	ctx := context.Background()
 
@@ -45,7 +50,9 @@ func (e *PostgresLO) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 
 	var oid uint32
 	err = tx.QueryRow(ctx, `SELECT value FROM kv WHERE key=$1`, key).Scan(&oid)
-	if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.Join(errors.WrapWith(err, ErrNotFound), tx.Rollback(ctx))
+	} else if err != nil {
 		return nil, errors.Join(err, tx.Rollback(ctx))
 	}
 
@@ -63,12 +70,13 @@ func (e *PostgresLO) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 func (e *PostgresLO) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	ctx := context.Background()
 
-	dbpool, err := pgxpool.New(ctx, benchmark.Postgres)
-	if err != nil {
-		return errors.WithStack(err)
+	dbpool, durability, errE := postgresPool(benchmark)
+	if errE != nil {
+		return errE
 	}
+	e.durability = durability
 	var maxConnectionsStr string
-	err = dbpool.QueryRow(ctx, `SHOW max_connections`).Scan(&maxConnectionsStr)
+	err := dbpool.QueryRow(ctx, `SHOW max_connections`).Scan(&maxConnectionsStr)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -80,11 +88,16 @@ func (e *PostgresLO) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 	if maxConnections < benchmark.Readers+benchmark.Writers+1 {
 		return errors.New("max_connections too low")
 	}
-	_, err = dbpool.Exec(ctx, `CREATE SEQUENCE kv_value_seq`)
+	// Without existing data, creating the sequence and the table fails if they already exist.
+	ifNotExists := ""
+	if benchmark.Existing {
+		ifNotExists = "IF NOT EXISTS "
+	}
+	_, err = dbpool.Exec(ctx, `CREATE SEQUENCE `+ifNotExists+`kv_value_seq`)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	_, err = dbpool.Exec(ctx, `CREATE TABLE kv (key BYTEA PRIMARY KEY NOT NULL, value OID NOT NULL DEFAULT nextval('kv_value_seq'))`)
+	_, err = dbpool.Exec(ctx, `CREATE TABLE `+ifNotExists+`kv (key BYTEA PRIMARY KEY NOT NULL, value OID NOT NULL DEFAULT nextval('kv_value_seq'))`)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+var _ volatileEngine = (*Redis)(nil)
+
+// Redis stores values in Redis or any other server speaking its protocol.
+//
+// If no address of the server is configured, an in-process stand-in
+// keeping data only in memory is started and accessed over TCP.
+type Redis struct {
+	client     *redis.Client
+	server     *miniredis.Miniredis
+	durability string
+}
+
+func (*Redis) Version(benchmark *Benchmark) (string, errors.E) {
+	v2, errE := getModuleVersion("github.com/redis/go-redis/v9")
+	if errE != nil {
+		return "", errE
+	}
+	if benchmark.Redis == "" {
+		v1, errE := getModuleVersion("github.com/alicebob/miniredis/v2")
+		if errE != nil {
+			return "", errE
+		}
+		return fmt.Sprintf("miniredis-%s/%s", v1, v2), nil
+	}
+
+	options, err := redis.ParseURL(benchmark.Redis)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	client := redis.NewClient(options)
+	defer client.Close()
+	v1, errE := redisInfo(context.Background(), client, "redis_version")
+	if errE != nil {
+		return "", errE
+	}
+	return fmt.Sprintf("%s/%s", v1, v2), nil
+}
+
+// redisInfo returns the value of the field from the "server" section of INFO.
+func redisInfo(ctx context.Context, client *redis.Client, field string) (string, errors.E) {
+	info, err := client.Info(ctx, "server").Result()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && name == field {
+			return value, nil
+		}
+	}
+	errE := errors.New("field not found in INFO")
+	errors.Details(errE)["field"] = field
+	return "", errE
+}
+
+// redisDurability returns durability of the server based on its persistence configuration.
+func redisDurability(ctx context.Context, client *redis.Client) (string, errors.E) {
+	config, err := client.ConfigGet(ctx, "append*").Result()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return redisConfigDurability(config), nil
+}
+
+// redisConfigDurability returns durability for the persistence configuration as returned by CONFIG GET.
+func redisConfigDurability(config map[string]string) string {
+	if config["appendonly"] != "yes" {
+		// RDB snapshots are made in the background and can lose any number of writes.
+		return durabilityNone
+	}
+	switch config["appendfsync"] {
+	case "always":
+		// Redis syncs AOF once for all writes made during one event loop iteration.
+		return durabilityGroup
+	case "everysec":
+		return durabilityPeriodic
+	default:
+		return durabilityNone
+	}
+}
+
+func (e *Redis) Close() errors.E {
+	err := e.client.Close()
+	e.closeServer()
+	return errors.WithStack(err)
+}
+
+func (*Redis) Sync() errors.E {
+	// Redis syncs AOF periodically itself, if configured to do so.
+	return nil
+}
+
+func (e *Redis) Durability() string {
+	return e.durability
+}
+
+func (e *Redis) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	value, err := e.client.Get(context.Background(), string(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return bytesReadSeekCloser(value, func() error {
+		return nil
+	}), nil
+}
+
+func (e *Redis) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	ctx := context.Background()
+
+	address := benchmark.Redis
+	if address == "" {
+		if benchmark.Existing {
+			return errors.New("existing data is not supported")
+		}
+		server := miniredis.NewMiniRedis()
+		err := server.Start()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		e.server = server
+		address = "redis://" + server.Addr()
+	}
+
+	options, err := redis.ParseURL(address)
+	if err != nil {
+		e.closeServer()
+		return errors.WithStack(err)
+	}
+	options.PoolSize = benchmark.Readers + benchmark.Writers + 1 // We add 1 just in case.
+	e.client = redis.NewClient(options)
+
+	if e.server != nil {
+		e.durability = durabilityNone
+		return nil
+	}
+
+	durability, errE := redisDurability(ctx, e.client)
+	if errE != nil {
+		return errors.Join(errE, e.Close())
+	}
+	if syncsWrites(benchmark.Durability) && !syncsWrites(durability) {
+		// We cannot change persistence configuration of a shared server, so we require it.
+		errE := errors.New("redis is not configured with appendonly yes and appendfsync always")
+		errors.Details(errE)["durability"] = durability
+		return errors.Join(errE, e.Close())
+	}
+	e.durability = durability
+
+	if !benchmark.Existing {
+		size, err := e.client.DBSize(ctx).Result()
+		if err != nil {
+			return errors.Join(err, e.Close())
+		}
+		if size > 0 {
+			return errors.Join(errors.New("database is not empty"), e.Close())
+		}
+	}
+
+	return nil
+}
+
+func (e *Redis) closeServer() {
+	if e.server != nil {
+		e.server.Close()
+	}
+}
+
+// volatile returns true when the in-process stand-in is used.
+func (*Redis) volatile(benchmark *Benchmark) bool {
+	return benchmark.Redis == ""
+}
+
+func (*Redis) Name() string {
+	return "redis"
+}
+
+func (e *Redis) Set(key []byte, value []byte) errors.E {
+	return errors.WithStack(e.client.Set(context.Background(), string(key), value, 0).Err())
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"gitlab.com/tozd/go/errors"
+)
+
+// Colors from cold (few samples) to hot (many samples).
+var heatMapColors = []string{ //nolint:gochecknoglobals
+	"#313695", "#4575b4", "#74add1", "#abd9e9", "#e0f3f8",
+	"#ffffbf", "#fee090", "#fdae61", "#f46d43", "#d73027", "#a50026",
+}
+
+func resultsTitle(results *Results) string {
+	title := fmt.Sprintf(
+		"%s [%d %d %s",
+		results.Engine, results.Readers, results.Writers, datasize.ByteSize(results.Size),
+	)
+	if results.Vary {
+		title += " vary"
+	}
+	if results.FS != "" {
+		title += " " + results.FS
+	}
+	return title + "]"
+}
+
+// latencyHeatMap renders latencies of the operation over time: time is on x axis,
+// latency bucket on y axis and color is the number of samples in the bucket.
+// Unlike averages, this shows stalls (e.g., compaction or checkpoints) as bands.
+func latencyHeatMap(results *Results, operation string) *charts.HeatMap {
+	// We limit y axis to buckets which have any samples.
+	minBucket, maxBucket := histogramBuckets, -1
+	maxCount := uint64(0)
+	for _, interval := range results.Intervals {
+		for i, c := range interval.Latencies[operation] {
+			if c == 0 {
+				continue
+			}
+			minBucket = min(minBucket, i)
+			maxBucket = max(maxBucket, i)
+			maxCount = max(maxCount, c)
+		}
+	}
+
+	xAxis := []string{}
+	data := []opts.HeatMapData{}
+	for x, interval := range results.Intervals {
+		xAxis = append(xAxis, interval.Start.String())
+		for i, c := range interval.Latencies[operation] {
+			if c == 0 {
+				continue
+			}
+			data = append(data, opts.HeatMapData{ //nolint:exhaustruct
+				Value: [3]interface{}{x, i - minBucket, c},
+			})
+		}
+	}
+	yAxis := []string{}
+	for i := minBucket; i <= maxBucket; i++ {
+		yAxis = append(yAxis, strconv.FormatFloat(histogramBucketBound(i), 'g', 3, 64)) //nolint:gomnd
+	}
+
+	heatMap := charts.NewHeatMap()
+	heatMap.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{ //nolint:exhaustruct
+			PageTitle: resultsTitle(results),
+			Width:     "1200px",
+		}),
+		charts.WithTitleOpts(opts.Title{ //nolint:exhaustruct
+			Title:    fmt.Sprintf("%s latency", operation),
+			Subtitle: "Number of operations per interval and latency bucket (upper bound in ms).",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}), //nolint:exhaustruct
+		// Heatmap does not set axis data itself, so we have to do it here.
+		charts.WithXAxisOpts(opts.XAxis{ //nolint:exhaustruct
+			Name: "time",
+			Type: "category",
+			Data: xAxis,
+		}),
+		charts.WithYAxisOpts(opts.YAxis{ //nolint:exhaustruct
+			Name: "ms",
+			Type: "category",
+			Data: yAxis,
+		}),
+		charts.WithVisualMapOpts(opts.VisualMap{ //nolint:exhaustruct
+			Calculable: true,
+			Min:        0,
+			Max:        float32(maxCount),
+			InRange: &opts.VisualMapInRange{ //nolint:exhaustruct
+				Color: heatMapColors,
+			},
+		}),
+	)
+	heatMap.AddSeries(operation, data)
+	return heatMap
+}
+
+// writeReport writes a HTML report with latency heatmaps for all operations.
+func writeReport(path string, results *Results) (errE errors.E) { //nolint:nonamedreturns
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		errE = errors.Join(errE, f.Close())
+	}()
+
+	page := components.NewPage()
+	page.PageTitle = resultsTitle(results)
+	for _, name := range resultsSamples {
+		if _, ok := results.Operations[name]; !ok {
+			continue
+		}
+		page.AddCharts(latencyHeatMap(results, name))
+	}
+
+	return errors.WithStack(page.Render(f))
+}
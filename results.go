@@ -0,0 +1,272 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+// Names of samples for which we compute results, in the order we report them.
+var resultsSamples = []string{"set", "get.ready", "get.first", "get.total", "get.miss", "set.mkdir"} //nolint:gochecknoglobals
+
+// resultsInterval holds measurements aggregated over one metrics interval.
+type resultsInterval struct {
+	samples  map[string]*histogram
+	counters map[string]float64
+}
+
+func newResultsInterval() *resultsInterval {
+	return &resultsInterval{
+		samples:  map[string]*histogram{},
+		counters: map[string]float64{},
+	}
+}
+
+func (i *resultsInterval) addSample(name string, val float64) {
+	h, ok := i.samples[name]
+	if !ok {
+		h = newHistogram()
+		i.samples[name] = h
+	}
+	h.add(val)
+}
+
+func (i *resultsInterval) operations(duration time.Duration) map[string]OperationResults {
+	operations := map[string]OperationResults{}
+	for name, h := range i.samples {
+		if !slices.Contains(resultsSamples, name) {
+			continue
+		}
+		// "get.ready", "get.first", and "get.total" all count bytes read.
+		op, _, _ := strings.Cut(name, ".")
+		throughput := i.counters[op+".bytes"] / duration.Seconds()
+		errorsName := op + ".errors"
+		switch name {
+		case "get.miss":
+			// Lookups of missing keys read no bytes and count their failures separately.
+			throughput = 0
+			errorsName = "get.miss.errors"
+		case "set.mkdir":
+			// Directories are created as part of sets which write and count failures themselves.
+			throughput = 0
+			errorsName = ""
+		}
+		operations[name] = OperationResults{
+			Count:      h.Count,
+			Errors:     uint64(i.counters[errorsName]),
+			Rate:       float64(h.Count) / duration.Seconds(),
+			Throughput: throughput,
+			Min:        h.Min,
+			Max:        h.Max,
+			Mean:       h.mean(),
+			P50:        h.quantile(0.50), //nolint:gomnd
+			P90:        h.quantile(0.90), //nolint:gomnd
+			P99:        h.quantile(0.99), //nolint:gomnd
+		}
+	}
+	return operations
+}
+
+func (i *resultsInterval) latencies() map[string][]uint64 {
+	latencies := map[string][]uint64{}
+	for name, h := range i.samples {
+		if !slices.Contains(resultsSamples, name) {
+			continue
+		}
+		latencies[name] = slices.Clone(h.Counts)
+	}
+	return latencies
+}
+
+var _ metrics.MetricSink = (*resultsSink)(nil)
+
+// resultsSink is a metrics sink which aggregates measurements over the whole
+// run (instead of only over the last interval like metrics.InmemSink does)
+// and over each metrics interval so that we can compute summary results at the end.
+type resultsSink struct {
+	mu        sync.Mutex
+	start     time.Time
+	interval  time.Duration
+	total     *resultsInterval
+	intervals []*resultsInterval
+}
+
+func newResultsSink(interval time.Duration) *resultsSink {
+	return &resultsSink{
+		mu:        sync.Mutex{},
+		start:     time.Now(),
+		interval:  interval,
+		total:     newResultsInterval(),
+		intervals: nil,
+	}
+}
+
+// current returns the interval for the current time. It must be called with the lock held.
+func (s *resultsSink) current() *resultsInterval {
+	i := int(time.Since(s.start) / s.interval)
+	for len(s.intervals) <= i {
+		s.intervals = append(s.intervals, newResultsInterval())
+	}
+	return s.intervals[i]
+}
+
+func (*resultsSink) SetGauge(_ []string, _ float32) {}
+
+func (*resultsSink) SetGaugeWithLabels(_ []string, _ float32, _ []metrics.Label) {}
+
+func (*resultsSink) EmitKey(_ []string, _ float32) {}
+
+func (s *resultsSink) IncrCounter(key []string, val float32) {
+	s.IncrCounterWithLabels(key, val, nil)
+}
+
+func (s *resultsSink) IncrCounterWithLabels(key []string, val float32, _ []metrics.Label) {
+	name := strings.Join(key, ".")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total.counters[name] += float64(val)
+	s.current().counters[name] += float64(val)
+}
+
+func (s *resultsSink) AddSample(key []string, val float32) {
+	s.AddSampleWithLabels(key, val, nil)
+}
+
+func (s *resultsSink) AddSampleWithLabels(key []string, val float32, _ []metrics.Label) {
+	name := strings.Join(key, ".")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total.addSample(name, float64(val))
+	s.current().addSample(name, float64(val))
+}
+
+// last computes results for the last completed interval, if there is any.
+func (s *resultsSink) last() (IntervalResults, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := int(time.Since(s.start)/s.interval) - 1
+	if i < 0 {
+		return IntervalResults{}, false //nolint:exhaustruct
+	}
+	interval := newResultsInterval()
+	// Intervals are created only when there are measurements in them.
+	if i < len(s.intervals) {
+		interval = s.intervals[i]
+	}
+	return IntervalResults{
+		Start:      time.Duration(i) * s.interval,
+		Duration:   s.interval,
+		Operations: interval.operations(s.interval),
+		Latencies:  interval.latencies(),
+	}, true
+}
+
+// results computes results for all measurements recorded so far.
+func (s *resultsSink) results() (time.Duration, map[string]OperationResults, []IntervalResults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	duration := time.Since(s.start)
+	intervals := []IntervalResults{}
+	for i, interval := range s.intervals {
+		start := time.Duration(i) * s.interval
+		// The last interval might be shorter.
+		d := min(s.interval, duration-start)
+		if d <= 0 {
+			continue
+		}
+		intervals = append(intervals, IntervalResults{
+			Start:      start,
+			Duration:   d,
+			Operations: interval.operations(d),
+			Latencies:  interval.latencies(),
+		})
+	}
+	return duration, s.total.operations(duration), intervals
+}
+
+// OperationResults are summary results for one operation. Rate is in operations
+// per second, throughput in bytes per second and latencies in milliseconds.
+// Errors is the number of tolerated failed operations.
+type OperationResults struct {
+	Count      uint64  `json:"count"`
+	Errors     uint64  `json:"errors,omitempty"`
+	Rate       float64 `json:"rate"`
+	Throughput float64 `json:"throughput"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Mean       float64 `json:"mean"`
+	P50        float64 `json:"p50"`
+	P90        float64 `json:"p90"`
+	P99        float64 `json:"p99"`
+}
+
+// IntervalResults are results for one metrics interval, starting at Start since
+// the beginning of the run. Latencies are histogram bucket counts for each operation.
+type IntervalResults struct {
+	Start      time.Duration               `json:"start"`
+	Duration   time.Duration               `json:"duration"`
+	Operations map[string]OperationResults `json:"operations"`
+	Latencies  map[string][]uint64         `json:"latencies"`
+}
+
+// DiskFullResults describe the state when the disk filled up.
+type DiskFullResults struct {
+	// How the engine reported the failure.
+	Error string `json:"error"`
+	// Keys and total size of values stored before the failure.
+	Keys  uint64 `json:"keys"`
+	Bytes uint64 `json:"bytes"`
+	// How many of stored keys were read back afterwards and how many of them were readable.
+	Checked  uint64 `json:"checked"`
+	Readable uint64 `json:"readable"`
+}
+
+// Results are metadata and summary results of one benchmark run.
+type Results struct {
+	Timestamp         time.Time                   `json:"timestamp"`
+	Engine            string                      `json:"engine"`
+	EngineVersion     string                      `json:"engineVersion"`
+	Writers           int                         `json:"writers"`
+	Readers           int                         `json:"readers"`
+	Size              uint64                      `json:"size"`
+	Vary              bool                        `json:"vary"`
+	Threads           int                         `json:"threads"`
+	ThreadsMultiplier float64                     `json:"threadsMultiplier"`
+	Memory            uint64                      `json:"memory"`
+	CPU               string                      `json:"cpu"`
+	Cores             int                         `json:"cores"`
+	GoRuntime         string                      `json:"goRuntime"`
+	GoCompile         string                      `json:"goCompile"`
+	FS                string                      `json:"fs"`
+	Durability        string                      `json:"durability"`
+	MaxKeys           uint64                      `json:"maxKeys,omitempty"`
+	MaxData           uint64                      `json:"maxData,omitempty"`
+	DiskFull          *DiskFullResults            `json:"diskFull,omitempty"`
+	Duration          time.Duration               `json:"duration"`
+	Operations        map[string]OperationResults `json:"operations"`
+	Intervals         []IntervalResults           `json:"intervals"`
+}
+
+func (r *Results) log(logger zerolog.Logger) {
+	for _, name := range resultsSamples {
+		op, ok := r.Operations[name]
+		if !ok {
+			continue
+		}
+		logger.Info().Uint64("count", op.Count).Uint64("errors", op.Errors).Float64("rate", op.Rate).Float64("throughput", op.Throughput).
+			Float64("min", op.Min).Float64("max", op.Max).Float64("mean", op.Mean).
+			Float64("p50", op.P50).Float64("p90", op.P90).Float64("p99", op.P99).
+			Dur("duration", r.Duration).
+			Msgf("summary %s", name)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3afero"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"gitlab.com/tozd/go/errors"
+)
+
+// Bucket used when the S3 address does not specify one.
+const s3DefaultBucket = "data"
+
+var _ Engine = (*S3)(nil)
+
+// S3 stores values as objects in S3-compatible object storage.
+//
+// If no address of object storage is configured, an in-process stand-in
+// storing objects in the data directory is started and accessed over HTTP.
+type S3 struct {
+	client     *minio.Core
+	bucket     string
+	server     *http.Server
+	dir        string
+	durability string
+}
+
+func (*S3) Version(_ *Benchmark) (string, errors.E) {
+	return getModuleVersion("github.com/minio/minio-go/v7")
+}
+
+func (e *S3) Close() errors.E {
+	if e.server == nil {
+		return nil
+	}
+	return errors.WithStack(e.server.Close())
+}
+
+func (e *S3) Sync() errors.E {
+	if e.server == nil {
+		// Object storage acknowledges writes only after they are durably stored.
+		return nil
+	}
+	// The stand-in does not sync files it writes.
+	return syncFS(e.dir)
+}
+
+func (e *S3) Durability() string {
+	return e.durability
+}
+
+func (e *S3) name(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+func (e *S3) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	ctx := context.Background()
+
+	body, info, _, err := e.client.GetObject(ctx, e.bucket, e.name(key), minio.GetObjectOptions{}) //nolint:exhaustruct
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return nil, errors.WrapWith(err, ErrNotFound)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &s3Reader{
+		engine: e,
+		key:    key,
+		size:   info.Size,
+		body:   body,
+		offset: 0,
+		next:   0,
+	}, nil
+}
+
+func (e *S3) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	ctx := context.Background()
+
+	address := benchmark.S3
+	if address == "" {
+		errE := e.startStandIn(benchmark)
+		if errE != nil {
+			return errE
+		}
+		address = "http://" + e.server.Addr
+	} else {
+		e.durability = durabilityFsync
+	}
+
+	errE := e.connect(address)
+	if errE != nil {
+		return errors.Join(errE, e.Close())
+	}
+
+	exists, err := e.client.BucketExists(ctx, e.bucket)
+	if err != nil {
+		return errors.Join(err, e.Close())
+	}
+	if !exists {
+		err = e.client.MakeBucket(ctx, e.bucket, minio.MakeBucketOptions{}) //nolint:exhaustruct
+		if err != nil {
+			return errors.Join(err, e.Close())
+		}
+	} else if !benchmark.Existing {
+		result, err := e.client.ListObjects(e.bucket, "", "", "", 1)
+		if err != nil {
+			return errors.Join(err, e.Close())
+		}
+		if len(result.Contents) > 0 {
+			return errors.Join(errors.New("bucket is not empty"), e.Close())
+		}
+	}
+
+	return nil
+}
+
+// startStandIn starts an in-process S3 stand-in storing objects in the data directory.
+func (e *S3) startStandIn(benchmark *Benchmark) errors.E {
+	err := os.MkdirAll(benchmark.Data, 0o700) //nolint:gomnd
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
+		return errors.New("data directory is not empty")
+	}
+	backend, err := s3afero.MultiBucket(afero.NewBasePathFs(afero.NewOsFs(), benchmark.Data))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.server = &http.Server{ //nolint:exhaustruct
+		Addr:              listener.Addr().String(),
+		Handler:           gofakes3.New(backend, gofakes3.WithIntegrityCheck(false)).Server(),
+		ReadHeaderTimeout: 10 * time.Second, //nolint:gomnd
+	}
+	go func() {
+		_ = e.server.Serve(listener)
+	}()
+	e.dir = benchmark.Data
+	if benchmark.Durability == durabilityPeriodic {
+		e.durability = durabilityPeriodic
+	} else {
+		e.durability = durabilityNone
+	}
+	return nil
+}
+
+// connect creates the client from the address in the form
+// "http(s)://[access-key:secret-key@]host[:port][/bucket]".
+func (e *S3) connect(address string) errors.E {
+	u, err := url.Parse(address)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		errE := errors.New("unsupported S3 address scheme")
+		errors.Details(errE)["scheme"] = u.Scheme
+		return errE
+	}
+	accessKey := u.User.Username()
+	secretKey, _ := u.User.Password()
+	e.bucket = strings.Trim(u.Path, "/")
+	if e.bucket == "" {
+		e.bucket = s3DefaultBucket
+	}
+	client, err := minio.NewCore(u.Host, &minio.Options{ //nolint:exhaustruct
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: u.Scheme == "https",
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	e.client = client
+	return nil
+}
+
+func (*S3) Name() string {
+	return "s3"
+}
+
+func (e *S3) Set(key []byte, value []byte) errors.E {
+	_, err := e.client.PutObject(
+		context.Background(), e.bucket, e.name(key), bytes.NewReader(value), int64(len(value)), "", "",
+		minio.PutObjectOptions{}, //nolint:exhaustruct
+	)
+	return errors.WithStack(err)
+}
+
+var _ io.ReadSeekCloser = (*s3Reader)(nil)
+
+// s3Reader streams the object body. Seeking only records the new offset and
+// the next read starts a ranged GET from it, unless the offset did not change.
+type s3Reader struct {
+	engine *S3
+	key    []byte
+	size   int64
+	body   io.ReadCloser
+	// Offset of the body.
+	offset int64
+	// Offset of the next read.
+	next int64
+}
+
+func (r *s3Reader) Read(p []byte) (int, error) {
+	if r.next >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil || r.offset != r.next {
+		errE := r.open()
+		if errE != nil {
+			return 0, errE
+		}
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	r.next = r.offset
+	return n, err //nolint:wrapcheck
+}
+
+// open (re)opens the body at the next read offset using a ranged GET.
+func (r *s3Reader) open() errors.E {
+	if r.body != nil {
+		err := r.body.Close()
+		r.body = nil
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	opts := minio.GetObjectOptions{} //nolint:exhaustruct
+	// SetRange(0, 0) would request only the first byte.
+	if r.next > 0 {
+		err := opts.SetRange(r.next, 0)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	body, _, _, err := r.engine.client.GetObject(context.Background(), r.engine.bucket, r.engine.name(r.key), opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.body = body
+	r.offset = r.next
+	return nil
+}
+
+func (r *s3Reader) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = r.next + offset
+	case io.SeekEnd:
+		next = r.size + offset
+	default:
+		return 0, errors.Errorf("invalid whence: %d", whence)
+	}
+	if next < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.next = next
+	return next, nil
+}
+
+func (r *s3Reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return errors.WithStack(err)
+}
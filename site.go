@@ -0,0 +1,597 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+// We use the same assets go-echarts uses by default.
+const siteEchartsURL = "https://go-echarts.github.io/go-echarts-assets/assets/echarts.min.js"
+
+// Site builds a static HTML site from log files of benchmark runs.
+//
+//nolint:lll
+type Site struct {
+	Logs   string `arg:""                             help:"Directory with log files (*.log) of benchmark runs, searched recursively."          placeholder:"DIR"           type:"existingdir"`
+	Output string `       default:"site" env:"OUTPUT" help:"Directory to write the site to. Default: ${default}. Environment variable: ${env}." placeholder:"DIR" short:"o"`
+}
+
+// siteLogEntry contains all fields we use from log entries.
+type siteLogEntry struct {
+	Message           string    `json:"message"`
+	Time              time.Time `json:"time"`
+	Engine            string    `json:"engine"`
+	EngineVersion     string    `json:"engineVersion"`
+	Writers           int       `json:"writers"`
+	Readers           int       `json:"readers"`
+	Size              uint64    `json:"size"`
+	Vary              bool      `json:"vary"`
+	Threads           int       `json:"threads"`
+	ThreadsMultiplier float64   `json:"threadsMultiplier"`
+	Memory            uint64    `json:"memory"`
+	CPU               string    `json:"cpu"`
+	Cores             int       `json:"cores"`
+	GoRuntime         string    `json:"goRuntime"`
+	GoCompile         string    `json:"goCompile"`
+	FS                string    `json:"fs"`
+	MaxKeys           uint64    `json:"maxKeys"`
+	MaxData           uint64    `json:"maxData"`
+	Achieved          string    `json:"achieved"`
+	Count             float64   `json:"count"`
+	Errors            float64   `json:"errors"`
+	Rate              float64   `json:"rate"`
+	Throughput        float64   `json:"throughput"`
+	Min               float64   `json:"min"`
+	Max               float64   `json:"max"`
+	Mean              float64   `json:"mean"`
+	P50               float64   `json:"p50"`
+	P90               float64   `json:"p90"`
+	P99               float64   `json:"p99"`
+	Duration          float64   `json:"duration"`
+}
+
+// sitePoint is a measurement at Time seconds since the start of the run.
+type sitePoint struct {
+	Time  float64
+	Value float64
+}
+
+type siteRun struct {
+	Results
+
+	ID       string
+	Scenario *siteScenario
+	File     string
+	// Rates of counters over time.
+	Rates map[string][]sitePoint
+	// Mean and max latencies of samples over time.
+	Means map[string][]sitePoint
+	Maxes map[string][]sitePoint
+}
+
+type siteScenarioKey struct {
+	Readers    int
+	Writers    int
+	Size       uint64
+	Vary       bool
+	FS         string
+	Durability string
+	MaxKeys    uint64
+	MaxData    uint64
+}
+
+type siteScenario struct {
+	siteScenarioKey
+
+	ID   string
+	Runs []*siteRun
+}
+
+func (s *siteScenario) Title() string {
+	title := fmt.Sprintf("%d readers, %d writers, %s", s.Readers, s.Writers, datasize.ByteSize(s.Size))
+	if s.Vary {
+		title += " (vary)"
+	}
+	if s.FS != "" {
+		title += ", " + s.FS
+	}
+	if s.Durability != "" {
+		title += ", " + s.Durability
+	}
+	if s.MaxKeys > 0 {
+		title += fmt.Sprintf(", max %d keys", s.MaxKeys)
+	}
+	if s.MaxData > 0 {
+		title += ", max " + datasize.ByteSize(s.MaxData).HR()
+	}
+	return title
+}
+
+func parseSiteLog(path string) ([]*siteRun, errors.E) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	runs := []*siteRun{}
+	var run *siteRun
+	var start time.Time
+	scanner := bufio.NewScanner(f)
+	// Some log lines (e.g., from engines) can be long.
+	scanner.Buffer(nil, 1024*1024) //nolint:gomnd
+	for scanner.Scan() {
+		var entry siteLogEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			// Not a JSON log line, we skip it.
+			continue
+		}
+		name, arg, _ := strings.Cut(entry.Message, " ")
+		switch {
+		case entry.Message == "running":
+			// The same log file can contain multiple runs.
+			start = entry.Time
+			run = &siteRun{
+				Results: Results{
+					Timestamp:         entry.Time,
+					Engine:            entry.Engine,
+					EngineVersion:     entry.EngineVersion,
+					Writers:           entry.Writers,
+					Readers:           entry.Readers,
+					Size:              entry.Size,
+					Vary:              entry.Vary,
+					Threads:           entry.Threads,
+					ThreadsMultiplier: entry.ThreadsMultiplier,
+					Memory:            entry.Memory,
+					CPU:               entry.CPU,
+					Cores:             entry.Cores,
+					GoRuntime:         entry.GoRuntime,
+					GoCompile:         entry.GoCompile,
+					FS:                entry.FS,
+					Durability:        "",
+					MaxKeys:           entry.MaxKeys,
+					MaxData:           entry.MaxData,
+					DiskFull:          nil,
+					Duration:          0,
+					Operations:        map[string]OperationResults{},
+					Intervals:         nil,
+				},
+				ID:       "",
+				Scenario: nil,
+				File:     path,
+				Rates:    map[string][]sitePoint{},
+				Means:    map[string][]sitePoint{},
+				Maxes:    map[string][]sitePoint{},
+			}
+			runs = append(runs, run)
+		case run == nil:
+			continue
+		case entry.Message == "durability":
+			run.Durability = entry.Achieved
+		case name == "counter":
+			t := entry.Time.Sub(start).Seconds()
+			run.Rates[arg] = append(run.Rates[arg], sitePoint{t, entry.Rate})
+		case name == "sample":
+			t := entry.Time.Sub(start).Seconds()
+			run.Means[arg] = append(run.Means[arg], sitePoint{t, entry.Mean})
+			run.Maxes[arg] = append(run.Maxes[arg], sitePoint{t, entry.Max})
+		case name == "summary":
+			run.Duration = time.Duration(entry.Duration * float64(time.Millisecond))
+			run.Operations[arg] = OperationResults{
+				Count:      uint64(entry.Count),
+				Errors:     uint64(entry.Errors),
+				Rate:       entry.Rate,
+				Throughput: entry.Throughput,
+				Min:        entry.Min,
+				Max:        entry.Max,
+				Mean:       entry.Mean,
+				P50:        entry.P50,
+				P90:        entry.P90,
+				P99:        entry.P99,
+			}
+		}
+	}
+	return runs, errors.WithStack(scanner.Err())
+}
+
+func siteID(parts ...interface{}) string {
+	s := []string{}
+	for _, part := range parts {
+		s = append(s, fmt.Sprintf("%v", part))
+	}
+	return strings.NewReplacer("/", "_", " ", "_").Replace(strings.Join(s, "-"))
+}
+
+type siteChart interface {
+	Validate()
+	JSONNotEscaped() template.HTML
+}
+
+func siteLineChart(title, yName string, series map[string][]sitePoint) *charts.Line {
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{ //nolint:exhaustruct
+			Width: "1200px",
+		}),
+		charts.WithTitleOpts(opts.Title{ //nolint:exhaustruct
+			Title: title,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true, Trigger: "axis"}), //nolint:exhaustruct
+		charts.WithLegendOpts(opts.Legend{Show: true, Top: "bottom"}),     //nolint:exhaustruct
+		charts.WithXAxisOpts(opts.XAxis{ //nolint:exhaustruct
+			Name: "s",
+			Type: "value",
+		}),
+		charts.WithYAxisOpts(opts.YAxis{ //nolint:exhaustruct
+			Name: yName,
+			Type: "value",
+		}),
+	)
+	names := []string{}
+	for name := range series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data := []opts.LineData{}
+		for _, point := range series[name] {
+			data = append(data, opts.LineData{Value: []float64{point.Time, point.Value}}) //nolint:exhaustruct
+		}
+		line.AddSeries(name, data)
+	}
+	return line
+}
+
+func siteBarChart(title, yName string, categories []string, series map[string][]float64) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{ //nolint:exhaustruct
+			Width: "1200px",
+		}),
+		charts.WithTitleOpts(opts.Title{ //nolint:exhaustruct
+			Title: title,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: true}),              //nolint:exhaustruct
+		charts.WithLegendOpts(opts.Legend{Show: true, Top: "bottom"}), //nolint:exhaustruct
+		charts.WithYAxisOpts(opts.YAxis{ //nolint:exhaustruct
+			Name: yName,
+			Type: "value",
+		}),
+	)
+	bar.SetXAxis(categories)
+	names := []string{}
+	for name := range series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data := []opts.BarData{}
+		for _, value := range series[name] {
+			data = append(data, opts.BarData{Value: value}) //nolint:exhaustruct
+		}
+		bar.AddSeries(name, data)
+	}
+	return bar
+}
+
+//nolint:gochecknoglobals
+var siteFuncs = template.FuncMap{
+	"size": func(size uint64) string {
+		return datasize.ByteSize(size).HR()
+	},
+	"float": func(value float64) string {
+		return fmt.Sprintf("%.3f", value)
+	},
+	"mbps": func(value float64) string {
+		return fmt.Sprintf("%.2f", value/1e6) //nolint:gomnd
+	},
+	"op": func(operations map[string]OperationResults, name string) *OperationResults {
+		op, ok := operations[name]
+		if !ok {
+			return nil
+		}
+		return &op
+	},
+	"chart": func(id string, chart siteChart) template.HTML {
+		chart.Validate()
+		return template.HTML(fmt.Sprintf( //nolint:gosec
+			`<div id="%s" class="chart"></div><script>echarts.init(document.getElementById("%s")).setOption(%s);</script>`,
+			id, id, chart.JSONNotEscaped(),
+		))
+	},
+}
+
+const siteHeader = `{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<script src="` + siteEchartsURL + `"></script>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: right; }
+th { background: #eee; }
+td.text { text-align: left; }
+.chart { width: 1200px; height: 500px; margin-bottom: 2em; }
+</style>
+</head>
+<body>
+<h1>{{.}}</h1>
+{{end}}`
+
+const siteIndex = siteHeader + `{{template "header" "Benchmark results"}}
+<p>
+{{range $name, $values := .Filters}}
+<label>{{$name}} <select data-filter="{{$name}}" onchange="filter()">
+<option value="">all</option>
+{{range $values}}<option>{{.}}</option>{{end}}
+</select></label>
+{{end}}
+</p>
+<table id="matrix">
+<tr><th>engine</th><th>readers</th><th>writers</th><th>size</th><th>vary</th><th>fs</th><th>durability</th><th>scenario</th>
+<th>set ops/s</th><th>set MB/s</th><th>set p99 ms</th><th>get ops/s</th><th>get MB/s</th><th>get p99 ms</th></tr>
+{{range .Runs}}
+<tr data-engine="{{.Engine}}" data-readers="{{.Readers}}" data-writers="{{.Writers}}" data-size="{{size .Size}}" data-vary="{{.Vary}}" data-fs="{{.FS}}" data-durability="{{.Durability}}">
+<td class="text"><a href="run-{{.ID}}.html">{{.Engine}}</a></td><td>{{.Readers}}</td><td>{{.Writers}}</td><td>{{size .Size}}</td><td>{{.Vary}}</td><td class="text">{{.FS}}</td><td class="text">{{.Durability}}</td>
+<td class="text"><a href="scenario-{{.Scenario.ID}}.html">compare</a></td>
+{{with op .Operations "set"}}<td>{{float .Rate}}</td><td>{{mbps .Throughput}}</td><td>{{float .P99}}</td>{{else}}<td>-</td><td>-</td><td>-</td>{{end}}
+{{with op .Operations "get.total"}}<td>{{float .Rate}}</td><td>{{mbps .Throughput}}</td><td>{{float .P99}}</td>{{else}}<td>-</td><td>-</td><td>-</td>{{end}}
+</tr>
+{{end}}
+</table>
+<script>
+function filter() {
+  const filters = Array.from(document.querySelectorAll("select[data-filter]")).filter((s) => s.value !== "");
+  for (const row of document.querySelectorAll("#matrix tr[data-engine]")) {
+    row.style.display = filters.every((s) => row.dataset[s.dataset.filter] === s.value) ? "" : "none";
+  }
+}
+</script>
+</body>
+</html>
+`
+
+const siteScenarioPage = siteHeader + `{{template "header" .Scenario.Title}}
+<p><a href="index.html">All results</a></p>
+<table>
+<tr><th>engine</th><th>version</th>
+<th>set ops/s</th><th>set MB/s</th><th>set p50 ms</th><th>set p99 ms</th>
+<th>get ops/s</th><th>get MB/s</th><th>get p50 ms</th><th>get p99 ms</th></tr>
+{{range .Scenario.Runs}}
+<tr><td class="text"><a href="run-{{.ID}}.html">{{.Engine}}</a></td><td class="text">{{.EngineVersion}}</td>
+{{with op .Operations "set"}}<td>{{float .Rate}}</td><td>{{mbps .Throughput}}</td><td>{{float .P50}}</td><td>{{float .P99}}</td>{{else}}<td>-</td><td>-</td><td>-</td><td>-</td>{{end}}
+{{with op .Operations "get.total"}}<td>{{float .Rate}}</td><td>{{mbps .Throughput}}</td><td>{{float .P50}}</td><td>{{float .P99}}</td>{{else}}<td>-</td><td>-</td><td>-</td><td>-</td>{{end}}
+</tr>
+{{end}}
+</table>
+{{range $i, $chart := .Charts}}{{chart (printf "chart%d" $i) $chart}}{{end}}
+</body>
+</html>
+`
+
+const siteRunPage = siteHeader + `{{template "header" .Title}}
+<p><a href="index.html">All results</a>, <a href="scenario-{{.Run.Scenario.ID}}.html">compare with other engines</a></p>
+{{with .Run}}
+<table>
+<tr><th>engine</th><td class="text">{{.Engine}} {{.EngineVersion}}</td></tr>
+<tr><th>readers</th><td class="text">{{.Readers}}</td></tr>
+<tr><th>writers</th><td class="text">{{.Writers}}</td></tr>
+<tr><th>size</th><td class="text">{{size .Size}}</td></tr>
+<tr><th>vary</th><td class="text">{{.Vary}}</td></tr>
+<tr><th>fs</th><td class="text">{{.FS}}</td></tr>
+<tr><th>durability</th><td class="text">{{.Durability}}</td></tr>
+{{if .MaxKeys}}<tr><th>max keys</th><td class="text">{{.MaxKeys}}</td></tr>{{end}}
+{{if .MaxData}}<tr><th>max data</th><td class="text">{{size .MaxData}}</td></tr>{{end}}
+<tr><th>started</th><td class="text">{{.Timestamp}}</td></tr>
+<tr><th>duration</th><td class="text">{{.Duration}}</td></tr>
+<tr><th>cpu</th><td class="text">{{.CPU}} ({{.Cores}} cores, {{.Threads}} threads)</td></tr>
+<tr><th>memory</th><td class="text">{{size .Memory}}</td></tr>
+<tr><th>go</th><td class="text">{{.GoRuntime}} (compiled with {{.GoCompile}})</td></tr>
+<tr><th>log</th><td class="text">{{.File}}</td></tr>
+</table>
+<table>
+<tr><th>operation</th><th>count</th><th>errors</th><th>ops/s</th><th>MB/s</th><th>min ms</th><th>mean ms</th><th>p50 ms</th><th>p90 ms</th><th>p99 ms</th><th>max ms</th></tr>
+{{range $name := $.Operations}}
+{{with op $.Run.Operations $name}}<tr><td class="text">{{$name}}</td><td>{{.Count}}</td><td>{{.Errors}}</td><td>{{float .Rate}}</td><td>{{mbps .Throughput}}</td><td>{{float .Min}}</td><td>{{float .Mean}}</td><td>{{float .P50}}</td><td>{{float .P90}}</td><td>{{float .P99}}</td><td>{{float .Max}}</td></tr>{{end}}
+{{end}}
+</table>
+{{end}}
+{{range $i, $chart := .Charts}}{{chart (printf "chart%d" $i) $chart}}{{end}}
+</body>
+</html>
+`
+
+func writeSitePage(path, tmpl string, data interface{}) errors.E {
+	t, err := template.New("page").Funcs(siteFuncs).Parse(tmpl)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, buf.Bytes(), 0o644)) //nolint:gomnd,gosec
+}
+
+func (s *Site) Run(logger zerolog.Logger) errors.E {
+	runs := []*siteRun{}
+	err := filepath.WalkDir(s.Logs, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".log" {
+			return nil
+		}
+		r, errE := parseSiteLog(path)
+		if errE != nil {
+			return errE
+		}
+		if len(r) == 0 {
+			logger.Warn().Str("file", path).Msg("no benchmark runs found in log file")
+		}
+		runs = append(runs, r...)
+		return nil
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		a, b := runs[i], runs[j]
+		if a.Engine != b.Engine {
+			return a.Engine < b.Engine
+		}
+		if a.Readers != b.Readers {
+			return a.Readers < b.Readers
+		}
+		if a.Writers != b.Writers {
+			return a.Writers < b.Writers
+		}
+		if a.Size != b.Size {
+			return a.Size < b.Size
+		}
+		if a.Vary != b.Vary {
+			return !a.Vary
+		}
+		if a.FS != b.FS {
+			return a.FS < b.FS
+		}
+		if a.Durability != b.Durability {
+			return a.Durability < b.Durability
+		}
+		if a.MaxKeys != b.MaxKeys {
+			return a.MaxKeys < b.MaxKeys
+		}
+		if a.MaxData != b.MaxData {
+			return a.MaxData < b.MaxData
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	})
+
+	scenarios := map[siteScenarioKey]*siteScenario{}
+	scenarioKeys := []siteScenarioKey{}
+	ids := map[string]int{}
+	filters := map[string][]string{}
+	addFilter := func(name string, value interface{}) {
+		v := fmt.Sprintf("%v", value)
+		if !slices.Contains(filters[name], v) {
+			filters[name] = append(filters[name], v)
+		}
+	}
+	for _, run := range runs {
+		key := siteScenarioKey{run.Readers, run.Writers, run.Size, run.Vary, run.FS, run.Durability, run.MaxKeys, run.MaxData}
+		scenario, ok := scenarios[key]
+		if !ok {
+			id := siteID(
+				key.Readers, key.Writers, datasize.ByteSize(key.Size), key.Vary, key.FS,
+				key.Durability, key.MaxKeys, datasize.ByteSize(key.MaxData),
+			)
+			scenario = &siteScenario{
+				siteScenarioKey: key,
+				ID:              id,
+				Runs:            nil,
+			}
+			scenarios[key] = scenario
+			scenarioKeys = append(scenarioKeys, key)
+		}
+		scenario.Runs = append(scenario.Runs, run)
+		run.Scenario = scenario
+		run.ID = siteID(run.Engine, scenario.ID)
+		// The same configuration might have been run multiple times.
+		ids[run.ID]++
+		if ids[run.ID] > 1 {
+			run.ID = siteID(run.ID, ids[run.ID])
+		}
+
+		addFilter("engine", run.Engine)
+		addFilter("readers", run.Readers)
+		addFilter("writers", run.Writers)
+		addFilter("size", datasize.ByteSize(run.Size).HR())
+		addFilter("vary", run.Vary)
+		addFilter("fs", run.FS)
+		addFilter("durability", run.Durability)
+	}
+
+	err = os.MkdirAll(s.Output, 0o755) //nolint:gomnd
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	errE := writeSitePage(filepath.Join(s.Output, "index.html"), siteIndex, map[string]interface{}{
+		"Runs":    runs,
+		"Filters": filters,
+	})
+	if errE != nil {
+		return errE
+	}
+
+	for _, key := range scenarioKeys {
+		scenario := scenarios[key]
+		engines := []string{}
+		rates := map[string][]float64{}
+		p99s := map[string][]float64{}
+		setRates := map[string][]sitePoint{}
+		getRates := map[string][]sitePoint{}
+		for _, run := range scenario.Runs {
+			engines = append(engines, run.ID)
+			for _, name := range []string{"set", "get.total"} {
+				op := run.Operations[name]
+				rates[name] = append(rates[name], op.Rate)
+				p99s[name] = append(p99s[name], op.P99)
+			}
+			setRates[run.ID] = run.Rates["set"]
+			getRates[run.ID] = run.Rates["get"]
+		}
+		errE := writeSitePage(filepath.Join(s.Output, "scenario-"+scenario.ID+".html"), siteScenarioPage, map[string]interface{}{
+			"Scenario": scenario,
+			"Charts": []siteChart{
+				siteBarChart("Operations per second", "ops/s", engines, rates),
+				siteBarChart("99th percentile latency", "ms", engines, p99s),
+				siteLineChart("set operations per second over time", "ops/s", setRates),
+				siteLineChart("get operations per second over time", "ops/s", getRates),
+			},
+		})
+		if errE != nil {
+			return errE
+		}
+	}
+
+	for _, run := range runs {
+		errE := writeSitePage(filepath.Join(s.Output, "run-"+run.ID+".html"), siteRunPage, map[string]interface{}{
+			"Title":      fmt.Sprintf("%s: %s", run.Engine, run.Scenario.Title()),
+			"Run":        run,
+			"Operations": resultsSamples,
+			"Charts": []siteChart{
+				siteLineChart("Operations per second", "ops/s", run.Rates),
+				siteLineChart("Mean latency", "ms", run.Means),
+				siteLineChart("Max latency", "ms", run.Maxes),
+			},
+		})
+		if errE != nil {
+			return errE
+		}
+	}
+
+	logger.Info().Int("runs", len(runs)).Int("scenarios", len(scenarios)).Str("output", s.Output).Msg("site generated")
+
+	return nil
+}
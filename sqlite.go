@@ -16,7 +16,8 @@ import (
 var _ Engine = (*Sqlite)(nil)
 
 type Sqlite struct {
-	dbpool *sqlitex.Pool
+	dbpool     *sqlitex.Pool
+	durability string
 }
 
 func (*Sqlite) Version(_ *Benchmark) (string, errors.E) {
@@ -49,8 +50,20 @@ func (e *Sqlite) Close() errors.E {
 }
 
 func (e *Sqlite) Sync() errors.E {
-	// Not supported. See: https://github.com/crawshaw/sqlite/issues/145
-	return nil
+	if e.durability != durabilityPeriodic {
+		// Not supported. See: https://github.com/crawshaw/sqlite/issues/145
+		return nil
+	}
+
+	// With synchronous set to NORMAL, WAL is synced on checkpoints.
+	// We do not pass context so that tracer is not setup.
+	conn := e.dbpool.Get(nil) //nolint:staticcheck
+	defer e.dbpool.Put(conn)
+	return errors.WithStack(sqlitex.ExecTransient(conn, `PRAGMA wal_checkpoint(FULL)`, nil))
+}
+
+func (e *Sqlite) Durability() string {
+	return e.durability
 }
 
 func (e *Sqlite) Get(key []byte) (io.ReadSeekCloser, errors.E) {
@@ -72,7 +85,7 @@ func (e *Sqlite) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 		return nil, errors.WithStack(err)
 	}
 	if !found {
-		err := errors.Base("not found") //nolint:govet
+		var err error = ErrNotFound //nolint:govet
 		tx(&err)
 		e.dbpool.Put(conn)
 		return nil, errors.WithStack(err)
@@ -93,13 +106,19 @@ func (e *Sqlite) Get(key []byte) (io.ReadSeekCloser, errors.E) {
 }
 
 func (e *Sqlite) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	return e.init(benchmark, `kv (key BLOB PRIMARY KEY NOT NULL, value BLOB NOT NULL)`)
+}
+
+// init opens the database and creates the table using its definition.
+func (e *Sqlite) init(benchmark *Benchmark, table string) errors.E {
 	err := os.MkdirAll(benchmark.Data, 0o700) //nolint:gomnd
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if !isEmpty(benchmark.Data) {
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
 		return errors.New("data directory is not empty")
 	}
+	poolSize := benchmark.Readers + benchmark.Writers + 1 // We add 1 just in case.
 	dbpool, err := sqlitex.Open(
 		path.Join(benchmark.Data, "data.db"),
 		sqlite.SQLITE_OPEN_READWRITE|
@@ -107,15 +126,50 @@ func (e *Sqlite) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
 			sqlite.SQLITE_OPEN_WAL|
 			sqlite.SQLITE_OPEN_NOMUTEX|
 			sqlite.SQLITE_OPEN_SHAREDCACHE,
-		benchmark.Readers+benchmark.Writers+1, // We add 1 just in case.
+		poolSize,
 	)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	var synchronous string
+	switch benchmark.Durability {
+	case durabilityNone:
+		synchronous = "OFF"
+		e.durability = durabilityNone
+	case durabilityPeriodic:
+		synchronous = "NORMAL"
+		e.durability = durabilityPeriodic
+	default:
+		// SQLite syncs WAL after every transaction and uses fdatasync on Linux.
+		synchronous = "FULL"
+		e.durability = durabilityFdatasync
+	}
+	// Synchronous setting is per connection, so we set it on all connections in the pool.
+	conns := []*sqlite.Conn{}
+	for i := 0; i < poolSize; i++ {
+		// We do not pass context so that tracer is not setup.
+		conn := dbpool.Get(nil) //nolint:staticcheck
+		conns = append(conns, conn)
+		err = sqlitex.ExecTransient(conn, fmt.Sprintf(`PRAGMA synchronous=%s`, synchronous), nil)
+		if err != nil {
+			break
+		}
+	}
+	for _, conn := range conns {
+		dbpool.Put(conn)
+	}
+	if err != nil {
+		return errors.Join(err, dbpool.Close())
+	}
 	// We do not pass context so that tracer is not setup.
 	conn := dbpool.Get(nil) //nolint:staticcheck
 	defer dbpool.Put(conn)
-	err = sqlitex.Exec(conn, `CREATE TABLE kv (key BLOB PRIMARY KEY NOT NULL, value BLOB NOT NULL)`, nil)
+	// Without existing data, creating the table fails if it already exists.
+	ifNotExists := ""
+	if benchmark.Existing {
+		ifNotExists = "IF NOT EXISTS "
+	}
+	err = sqlitex.Exec(conn, `CREATE TABLE `+ifNotExists+table, nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+	_ "modernc.org/sqlite" // Registers the "sqlite" database/sql driver.
+)
+
+var _ Engine = (*SqliteGo)(nil)
+
+// SqliteGo is the same as Sqlite, but uses a pure-Go translation of SQLite
+// through database/sql instead of cgo.
+//
+// database/sql does not provide blob I/O, so values are read and written whole.
+type SqliteGo struct {
+	db         *sql.DB
+	durability string
+}
+
+func (*SqliteGo) Version(_ *Benchmark) (string, errors.E) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer db.Close()
+	var v1 string
+	err = db.QueryRow(`SELECT sqlite_version()`).Scan(&v1)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	v2, errE := getModuleVersion("modernc.org/sqlite")
+	if errE != nil {
+		return "", errE
+	}
+	return fmt.Sprintf("%s/%s", v1, v2), nil
+}
+
+func (e *SqliteGo) Close() errors.E {
+	return errors.WithStack(e.db.Close())
+}
+
+func (e *SqliteGo) Sync() errors.E {
+	if e.durability != durabilityPeriodic {
+		return nil
+	}
+
+	// With synchronous set to NORMAL, WAL is synced on checkpoints.
+	_, err := e.db.Exec(`PRAGMA wal_checkpoint(FULL)`)
+	return errors.WithStack(err)
+}
+
+func (e *SqliteGo) Durability() string {
+	return e.durability
+}
+
+func (e *SqliteGo) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	ctx := context.Background()
+
+	tx, err := e.db.BeginTx(ctx, &sql.TxOptions{ //nolint:exhaustruct
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var value []byte
+	err = tx.QueryRowContext(ctx, `SELECT value FROM kv WHERE key=?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Join(errors.WrapWith(err, ErrNotFound), tx.Rollback())
+	} else if err != nil {
+		return nil, errors.Join(err, tx.Rollback())
+	}
+
+	return bytesReadSeekCloser(value, func() error {
+		return errors.WithStack(tx.Rollback())
+	}), nil
+}
+
+func (e *SqliteGo) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	err := os.MkdirAll(benchmark.Data, 0o700) //nolint:gomnd
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !benchmark.Existing && !isEmpty(benchmark.Data) {
+		return errors.New("data directory is not empty")
+	}
+	var synchronous string
+	switch benchmark.Durability {
+	case durabilityNone:
+		synchronous = "OFF"
+		e.durability = durabilityNone
+	case durabilityPeriodic:
+		synchronous = "NORMAL"
+		e.durability = durabilityPeriodic
+	default:
+		// SQLite syncs WAL after every transaction and uses fdatasync on Linux.
+		synchronous = "FULL"
+		e.durability = durabilityFdatasync
+	}
+	// Pragmas are run on every new connection.
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", fmt.Sprintf("synchronous(%s)", synchronous))
+	// Connections do not share cache like they do with the Sqlite engine,
+	// so concurrent writers wait for each other.
+	query.Add("_pragma", "busy_timeout(10000)")
+	db, err := sql.Open("sqlite", "file:"+path.Join(benchmark.Data, "data.db")+"?"+query.Encode())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	poolSize := benchmark.Readers + benchmark.Writers + 1 // We add 1 just in case.
+	db.SetMaxOpenConns(poolSize)
+	db.SetMaxIdleConns(poolSize)
+	// Without existing data, creating the table fails if it already exists.
+	ifNotExists := ""
+	if benchmark.Existing {
+		ifNotExists = "IF NOT EXISTS "
+	}
+	_, err = db.Exec(`CREATE TABLE ` + ifNotExists + `kv (key BLOB PRIMARY KEY NOT NULL, value BLOB NOT NULL)`)
+	if err != nil {
+		return errors.Join(err, db.Close())
+	}
+	e.db = db
+	return nil
+}
+
+func (*SqliteGo) Name() string {
+	return "sqlitego"
+}
+
+func (e *SqliteGo) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedreturns
+	ctx := context.Background()
+
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		err := tx.Rollback() //nolint:govet
+		if errors.Is(err, sql.ErrTxDone) {
+			err = nil
+		}
+		errE = errors.Join(errE, err)
+	}()
+
+	_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO kv (key, value) VALUES (?, ?)`, key, value)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(tx.Commit())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+
+	"crawshaw.io/sqlite"
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/rs/zerolog"
+	"gitlab.com/tozd/go/errors"
+)
+
+var _ Engine = (*SqliteInline)(nil)
+
+// SqliteInline is the same as Sqlite, but binds values directly in statements
+// and reads them as regular columns instead of using blob I/O.
+type SqliteInline struct {
+	Sqlite
+}
+
+func (e *SqliteInline) Get(key []byte) (io.ReadSeekCloser, errors.E) {
+	// We do not pass context so that tracer is not setup.
+	conn := e.dbpool.Get(nil) //nolint:staticcheck
+
+	tx := sqlitex.Save(conn)
+
+	var value []byte
+	err := sqlitex.Exec(conn, `SELECT value FROM kv WHERE key=?`, func(stmt *sqlite.Stmt) error {
+		// Column bytes are valid only until the next step, so we have to copy them.
+		value = make([]byte, stmt.ColumnLen(0))
+		stmt.ColumnBytes(0, value)
+		return nil
+	}, key)
+	if err != nil {
+		tx(&err)
+		e.dbpool.Put(conn)
+		return nil, errors.WithStack(err)
+	}
+	if value == nil {
+		var err error = ErrNotFound //nolint:govet
+		tx(&err)
+		e.dbpool.Put(conn)
+		return nil, errors.WithStack(err)
+	}
+	return bytesReadSeekCloser(value, func() error {
+		var err error
+		tx(&err)
+		e.dbpool.Put(conn)
+		return errors.WithStack(err)
+	}), nil
+}
+
+func (e *SqliteInline) Init(benchmark *Benchmark, _ zerolog.Logger) errors.E {
+	table := `kv (key BLOB PRIMARY KEY NOT NULL, value BLOB NOT NULL)`
+	if benchmark.SqliteWithoutRowid {
+		table += ` WITHOUT ROWID`
+	}
+	return e.init(benchmark, table)
+}
+
+func (*SqliteInline) Name() string {
+	return "sqliteinline"
+}
+
+func (e *SqliteInline) Set(key []byte, value []byte) (errE errors.E) { //nolint:nonamedreturns
+	// We do not pass context so that tracer is not setup.
+	conn := e.dbpool.Get(nil) //nolint:staticcheck
+	defer e.dbpool.Put(conn)
+
+	tx := sqlitex.Save(conn)
+	defer func() {
+		// Calling "tx" already joins errors.
+		var err error = errE
+		tx(&err)
+		errE = errors.WithStack(err)
+	}()
+
+	stmt := conn.Prep(`INSERT OR REPLACE INTO kv (key, value) VALUES ($key, $value)`)
+	stmt.SetBytes("$key", key)
+	stmt.SetBytes("$value", value)
+	_, err := stmt.Step()
+	return errors.WithStack(err)
+}
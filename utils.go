@@ -7,9 +7,11 @@ import (
 	"io"
 	"runtime/debug"
 	"slices"
+	"time"
 
 	"github.com/hashicorp/go-metrics"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 	"gitlab.com/tozd/go/errors"
 )
@@ -61,14 +63,14 @@ type metricsEncoder struct {
 func (e metricsEncoder) Encode(value interface{}) error {
 	if v, ok := value.(metrics.MetricsSummary); ok {
 		for _, counter := range v.Counters {
-			if slices.Contains([]string{"set", "get"}, counter.Name) {
+			if slices.Contains([]string{"set", "get", "get.miss", "set.errors", "get.errors", "get.miss.errors"}, counter.Name) {
 				e.Logger.Info().Float64("rate", counter.Rate).Int("count", counter.Count).
 					Str("timestamp", v.Timestamp).
 					Msgf("counter %s", counter.Name)
 			}
 		}
 		for _, sample := range v.Samples {
-			if slices.Contains([]string{"set", "get.ready", "get.total", "get.first"}, sample.Name) {
+			if slices.Contains([]string{"set", "get.ready", "get.total", "get.first", "get.miss", "set.mkdir"}, sample.Name) {
 				e.Logger.Info().Float64("min", sample.Min).Float64("max", sample.Max).
 					Float64("mean", sample.Mean).Str("timestamp", v.Timestamp).
 					Msgf("sample %s", sample.Name)
@@ -106,6 +108,30 @@ func getGoCompile() string {
 	return buildInfo.GoVersion
 }
 
+// postgresPool connects to PostgreSQL with synchronous_commit set based on durability.
+// It returns durability achieved.
+func postgresPool(benchmark *Benchmark) (*pgxpool.Pool, string, errors.E) {
+	config, err := pgxpool.ParseConfig(benchmark.Postgres)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	var durability string
+	if syncsWrites(benchmark.Durability) {
+		config.ConnConfig.RuntimeParams["synchronous_commit"] = "on"
+		// PostgreSQL syncs WAL for concurrent commits together.
+		durability = durabilityGroup
+	} else {
+		// WAL writer still syncs WAL periodically.
+		config.ConnConfig.RuntimeParams["synchronous_commit"] = "off"
+		durability = durabilityPeriodic
+	}
+	dbpool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	return dbpool, durability, nil
+}
+
 func postgresVersion(connString string) (string, errors.E) {
 	conn, err := pgx.Connect(context.Background(), connString)
 	if err != nil {
@@ -123,3 +149,8 @@ func postgresVersion(connString string) (string, errors.E) {
 	}
 	return fmt.Sprintf("%s/%s", v1, v2), nil
 }
+
+// milliseconds returns the duration in fractional milliseconds, the unit used by metrics.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}